@@ -11,6 +11,7 @@ import (
 
 	"github.com/Goga74/platform2/internal/common/config"
 	"github.com/Goga74/platform2/internal/common/swagger"
+	"github.com/Goga74/platform2/internal/metrics"
 	"github.com/Goga74/platform2/projects/strike2"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -48,6 +49,9 @@ func main() {
 	// Swagger documentation
 	swagger.RegisterRoutes(r)
 
+	// Prometheus-compatible metrics
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+
 	// --- Project: Strike2 ---
 	s2Cfg := strike2.LoadConfig()
 	s2, err := strike2.New(s2Cfg)
@@ -0,0 +1,80 @@
+package database
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+)
+
+// Migrate applies every *.sql file under dir in fsys, in lexical filename
+// order, recording each as it's applied in a schema_migrations table so
+// re-running Migrate is a no-op once a project's schema is up to date.
+func (db *DB) Migrate(fsys embed.FS, dir string) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		name TEXT PRIMARY KEY,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return fmt.Errorf("failed to read migrations dir %q: %w", dir, err)
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if !e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		var applied bool
+		if err := db.QueryRow(
+			`SELECT EXISTS(SELECT 1 FROM schema_migrations WHERE name = $1)`, name,
+		).Scan(&applied); err != nil {
+			return fmt.Errorf("failed to check migration %q: %w", name, err)
+		}
+		if applied {
+			continue
+		}
+
+		contents, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if err != nil {
+			return fmt.Errorf("failed to read migration %q: %w", name, err)
+		}
+
+		if err := db.applyMigration(name, string(contents)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (db *DB) applyMigration(name, sqlText string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin migration %q: %w", name, err)
+	}
+
+	if _, err := tx.Exec(sqlText); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to apply migration %q: %w", name, err)
+	}
+
+	if _, err := tx.Exec(`INSERT INTO schema_migrations (name) VALUES ($1)`, name); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to record migration %q: %w", name, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %q: %w", name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,270 @@
+// Package metrics is a minimal Prometheus-compatible metrics registry.
+// It depends on nothing outside the standard library: callers register
+// Counters, Gauges, and Histograms and serve them with Handler().
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefBuckets are the default histogram bucket boundaries, in seconds,
+// matching the Prometheus client libraries' usual defaults.
+var DefBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type metric interface {
+	Name() string
+	write(w *strings.Builder)
+}
+
+type registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+var defaultRegistry = &registry{}
+
+func (r *registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics = append(r.metrics, m)
+}
+
+// Handler serves every registered metric in the Prometheus text exposition format.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defaultRegistry.mu.Lock()
+		snapshot := append([]metric(nil), defaultRegistry.metrics...)
+		defaultRegistry.mu.Unlock()
+
+		sort.Slice(snapshot, func(i, j int) bool { return snapshot[i].Name() < snapshot[j].Name() })
+
+		var b strings.Builder
+		for _, m := range snapshot {
+			m.write(&b)
+		}
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.Write([]byte(b.String()))
+	})
+}
+
+type metricFamily struct {
+	name       string
+	help       string
+	typeName   string
+	labelNames []string
+}
+
+func (m *metricFamily) Name() string { return m.name }
+
+func (m *metricFamily) labelKey(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+type labeledValue struct {
+	labels []string
+	value  float64
+}
+
+// Counter is a monotonically increasing value, optionally partitioned by labels.
+type Counter struct {
+	metricFamily
+	mu     sync.Mutex
+	values map[string]*labeledValue
+}
+
+// NewCounter registers and returns a new Counter. labelNames declares the
+// label set every Inc/Add call must supply values for, in order.
+func NewCounter(name, help string, labelNames ...string) *Counter {
+	c := &Counter{
+		metricFamily: metricFamily{name: name, help: help, typeName: "counter", labelNames: labelNames},
+		values:       make(map[string]*labeledValue),
+	}
+	defaultRegistry.register(c)
+	return c
+}
+
+// Inc increments the counter for the given label values by 1.
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add increments the counter for the given label values by delta.
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	key := c.labelKey(labelValues)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.values[key]
+	if !ok {
+		v = &labeledValue{labels: append([]string(nil), labelValues...)}
+		c.values[key] = v
+	}
+	v.value += delta
+}
+
+func (c *Counter) write(w *strings.Builder) {
+	writeHeader(w, c.name, c.help, c.typeName)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, v := range c.values {
+		writeSample(w, c.name, c.labelNames, v.labels, v.value)
+	}
+}
+
+// Gauge is a value that can go up or down, optionally partitioned by labels.
+type Gauge struct {
+	metricFamily
+	mu     sync.Mutex
+	values map[string]*labeledValue
+}
+
+// NewGauge registers and returns a new Gauge.
+func NewGauge(name, help string, labelNames ...string) *Gauge {
+	g := &Gauge{
+		metricFamily: metricFamily{name: name, help: help, typeName: "gauge", labelNames: labelNames},
+		values:       make(map[string]*labeledValue),
+	}
+	defaultRegistry.register(g)
+	return g
+}
+
+// Set sets the gauge for the given label values.
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	key := g.labelKey(labelValues)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	v, ok := g.values[key]
+	if !ok {
+		v = &labeledValue{labels: append([]string(nil), labelValues...)}
+		g.values[key] = v
+	}
+	v.value = value
+}
+
+// Inc increments the gauge for the given label values by 1.
+func (g *Gauge) Inc(labelValues ...string) { g.Add(1, labelValues...) }
+
+// Dec decrements the gauge for the given label values by 1.
+func (g *Gauge) Dec(labelValues ...string) { g.Add(-1, labelValues...) }
+
+// Add adds delta to the gauge for the given label values.
+func (g *Gauge) Add(delta float64, labelValues ...string) {
+	key := g.labelKey(labelValues)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	v, ok := g.values[key]
+	if !ok {
+		v = &labeledValue{labels: append([]string(nil), labelValues...)}
+		g.values[key] = v
+	}
+	v.value += delta
+}
+
+func (g *Gauge) write(w *strings.Builder) {
+	writeHeader(w, g.name, g.help, g.typeName)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, v := range g.values {
+		writeSample(w, g.name, g.labelNames, v.labels, v.value)
+	}
+}
+
+// Histogram tracks an observation count and sum across fixed bucket
+// boundaries, optionally partitioned by labels.
+type Histogram struct {
+	metricFamily
+	buckets []float64
+	mu      sync.Mutex
+	values  map[string]*histogramValue
+}
+
+type histogramValue struct {
+	labels []string
+	counts []uint64
+	total  uint64
+	sum    float64
+}
+
+// NewHistogram registers and returns a new Histogram with the given bucket
+// boundaries (upper bounds, ascending).
+func NewHistogram(name, help string, buckets []float64, labelNames ...string) *Histogram {
+	h := &Histogram{
+		metricFamily: metricFamily{name: name, help: help, typeName: "histogram", labelNames: labelNames},
+		buckets:      append([]float64(nil), buckets...),
+		values:       make(map[string]*histogramValue),
+	}
+	defaultRegistry.register(h)
+	return h
+}
+
+// Observe records value for the given label values.
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	key := h.labelKey(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	v, ok := h.values[key]
+	if !ok {
+		v = &histogramValue{labels: append([]string(nil), labelValues...), counts: make([]uint64, len(h.buckets))}
+		h.values[key] = v
+	}
+	for i, bound := range h.buckets {
+		if value <= bound {
+			v.counts[i]++
+		}
+	}
+	v.total++
+	v.sum += value
+}
+
+func (h *Histogram) write(w *strings.Builder) {
+	writeHeader(w, h.name, h.help, h.typeName)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	bucketLabelNames := append(append([]string(nil), h.labelNames...), "le")
+
+	for _, v := range h.values {
+		for i, bound := range h.buckets {
+			bucketLabelValues := append(append([]string(nil), v.labels...), formatFloat(bound))
+			writeSample(w, h.name+"_bucket", bucketLabelNames, bucketLabelValues, float64(v.counts[i]))
+		}
+		infLabelValues := append(append([]string(nil), v.labels...), "+Inf")
+		writeSample(w, h.name+"_bucket", bucketLabelNames, infLabelValues, float64(v.total))
+
+		writeSample(w, h.name+"_sum", h.labelNames, v.labels, v.sum)
+		writeSample(w, h.name+"_count", h.labelNames, v.labels, float64(v.total))
+	}
+}
+
+func writeHeader(w *strings.Builder, name, help, typeName string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, typeName)
+}
+
+func writeSample(w *strings.Builder, name string, labelNames, labelValues []string, value float64) {
+	w.WriteString(name)
+	if len(labelNames) > 0 {
+		w.WriteString("{")
+		for i, ln := range labelNames {
+			if i > 0 {
+				w.WriteString(",")
+			}
+			fmt.Fprintf(w, "%s=%q", ln, labelValues[i])
+		}
+		w.WriteString("}")
+	}
+	fmt.Fprintf(w, " %s\n", formatFloat(value))
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,66 @@
+package metrics
+
+// Metrics shared by Strike2's proxy, scraper, and captcha components.
+var (
+	// RequestsTotal counts every proxy and scraper request, labeled by the
+	// fingerprint used, the resulting status code, and the target host.
+	RequestsTotal = NewCounter(
+		"strike2_requests_total",
+		"Total number of requests handled, labeled by fingerprint, status, and host.",
+		"fingerprint", "status", "host",
+	)
+
+	// FetchDurationSeconds times a scraper.FetchURL call end to end, labeled by host.
+	FetchDurationSeconds = NewHistogram(
+		"strike2_fetch_duration_seconds",
+		"Time spent performing a single scraper fetch, in seconds.",
+		DefBuckets,
+		"host",
+	)
+
+	// TLSHandshakeDurationSeconds times a proxy CONNECT tunnel's dial to the
+	// target, immediately preceding the client's own TLS handshake over it.
+	TLSHandshakeDurationSeconds = NewHistogram(
+		"strike2_tls_handshake_duration_seconds",
+		"Time spent dialing a CONNECT tunnel's target, in seconds.",
+		DefBuckets,
+		"host",
+	)
+
+	// CaptchaSolveDurationSeconds times a captcha.Solver.Solve call, labeled
+	// by provider and challenge type.
+	CaptchaSolveDurationSeconds = NewHistogram(
+		"strike2_captcha_solve_duration_seconds",
+		"Time spent solving a captcha challenge, in seconds.",
+		DefBuckets,
+		"provider", "challenge_type",
+	)
+
+	// UpstreamDialDurationSeconds times the proxy's dial to the first hop of
+	// an upstream proxy chain, labeled by hop scheme.
+	UpstreamDialDurationSeconds = NewHistogram(
+		"strike2_upstream_dial_duration_seconds",
+		"Time spent dialing the first hop of an upstream proxy chain, in seconds.",
+		DefBuckets,
+		"scheme",
+	)
+
+	// WorkerPoolInflight is the number of scraper fetches currently in flight.
+	WorkerPoolInflight = NewGauge(
+		"strike2_worker_pool_inflight",
+		"Number of fetches currently in flight through the scraper's worker pool.",
+	)
+
+	// ClientCacheSize is the number of cached uTLS clients, keyed by fingerprint.
+	ClientCacheSize = NewGauge(
+		"strike2_client_cache_size",
+		"Number of cached uTLS clients, keyed by fingerprint.",
+	)
+
+	// HostQueueDepth is the number of jobs currently queued for a host's delivery worker.
+	HostQueueDepth = NewGauge(
+		"strike2_host_queue_depth",
+		"Number of jobs currently queued for a host's delivery worker.",
+		"host",
+	)
+)
@@ -0,0 +1,71 @@
+package transport
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	utls "github.com/refraction-networking/utls"
+	"gopkg.in/yaml.v3"
+)
+
+// fingerprintFile is the on-disk YAML shape parsed by LoadFingerprintsFromFile.
+type fingerprintFile struct {
+	Fingerprints []fingerprintEntry `yaml:"fingerprints"`
+}
+
+// fingerprintEntry describes one Fingerprint in a LoadFingerprintsFromFile
+// YAML file. HexClientHello is a hex-encoded raw ClientHello captured from
+// the browser being mimicked (e.g. via Wireshark), parsed into a
+// ClientHelloSpec with uTLS's Fingerprinter.
+type fingerprintEntry struct {
+	Name           string `yaml:"name"`
+	UserAgent      string `yaml:"user_agent"`
+	HexClientHello string `yaml:"hex_client_hello"`
+}
+
+// LoadFingerprintsFromFile parses a YAML file of captured-ClientHello
+// fingerprints (see fingerprintEntry) into Fingerprints with a Spec built
+// from each entry's hex-encoded ClientHello, so operators can refresh pinned
+// fingerprints when a CDN's JA3 blocklist catches up to the built-in
+// GetFingerprints presets, without recompiling.
+func LoadFingerprintsFromFile(path string) ([]Fingerprint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fingerprints file %q: %w", path, err)
+	}
+
+	var file fingerprintFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse fingerprints file %q: %w", path, err)
+	}
+
+	fps := make([]Fingerprint, 0, len(file.Fingerprints))
+	for _, entry := range file.Fingerprints {
+		if entry.Name == "" {
+			return nil, fmt.Errorf("fingerprints file %q: entry missing required name", path)
+		}
+		if entry.HexClientHello == "" {
+			return nil, fmt.Errorf("fingerprints file %q: entry %q missing hex_client_hello", path, entry.Name)
+		}
+
+		raw, err := hex.DecodeString(entry.HexClientHello)
+		if err != nil {
+			return nil, fmt.Errorf("fingerprints file %q: entry %q has invalid hex_client_hello: %w", path, entry.Name, err)
+		}
+
+		spec, err := (&utls.Fingerprinter{AllowBluntMimicry: true}).FingerprintClientHello(raw)
+		if err != nil {
+			return nil, fmt.Errorf("fingerprints file %q: entry %q: failed to fingerprint captured ClientHello: %w", path, entry.Name, err)
+		}
+
+		fps = append(fps, Fingerprint{
+			ID:        utls.HelloCustom,
+			Name:      entry.Name,
+			UserAgent: entry.UserAgent,
+			Spec:      spec,
+		})
+	}
+
+	return fps, nil
+}
@@ -0,0 +1,209 @@
+package transport
+
+import (
+	"container/list"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// FingerprintStrategy selects how a Fingerprint is chosen for each request.
+type FingerprintStrategy string
+
+const (
+	StrategyFixed            FingerprintStrategy = "fixed"
+	StrategyRandomPerRequest FingerprintStrategy = "random-per-request"
+	StrategyStickyPerHost    FingerprintStrategy = "sticky-per-host"
+	StrategyRoundRobin       FingerprintStrategy = "round-robin"
+	StrategyWeighted         FingerprintStrategy = "weighted"
+)
+
+// defaultStickyLRUSize bounds how many host -> fingerprint mappings
+// sticky-per-host keeps before evicting the least recently used.
+const defaultStickyLRUSize = 4096
+
+// FingerprintSelector chooses a Fingerprint for each request according to a
+// configured FingerprintStrategy. A host pinned via Pin always overrides the
+// strategy for that host, until Unpin is called.
+type FingerprintSelector struct {
+	strategy     FingerprintStrategy
+	fixed        Fingerprint
+	fingerprints []Fingerprint
+	weights      []int
+
+	rrCounter uint64
+
+	stickyMu sync.Mutex
+	sticky   *list.List
+	stickyM  map[string]*list.Element
+
+	pinsMu sync.RWMutex
+	pins   map[string]Fingerprint
+}
+
+// stickyEntry is the value stored in the sticky-per-host LRU.
+type stickyEntry struct {
+	host string
+	fp   Fingerprint
+}
+
+// NewFingerprintSelector builds a selector for strategy. fixedName names the
+// fingerprint used by StrategyFixed, falling back to the first available
+// fingerprint if empty or unrecognized. weights maps a fingerprint name
+// (case-insensitive) to its relative weight for StrategyWeighted; names not
+// present default to weight 0.
+func NewFingerprintSelector(strategy FingerprintStrategy, fixedName string, weights map[string]int) (*FingerprintSelector, error) {
+	switch strategy {
+	case StrategyFixed, StrategyRandomPerRequest, StrategyStickyPerHost, StrategyRoundRobin, StrategyWeighted:
+	default:
+		return nil, fmt.Errorf("unknown fingerprint strategy %q", strategy)
+	}
+
+	fps := GetFingerprints()
+
+	sel := &FingerprintSelector{
+		strategy:     strategy,
+		fingerprints: fps,
+		fixed:        fps[0],
+		sticky:       list.New(),
+		stickyM:      make(map[string]*list.Element),
+		pins:         make(map[string]Fingerprint),
+	}
+
+	if fixedName != "" {
+		for _, fp := range fps {
+			if strings.EqualFold(fp.Name, fixedName) {
+				sel.fixed = fp
+				break
+			}
+		}
+	}
+
+	if strategy == StrategyWeighted {
+		lowered := make(map[string]int, len(weights))
+		for name, w := range weights {
+			lowered[strings.ToLower(name)] = w
+		}
+		sel.weights = make([]int, len(fps))
+		total := 0
+		for i, fp := range fps {
+			w := lowered[strings.ToLower(fp.Name)]
+			sel.weights[i] = w
+			total += w
+		}
+		if total == 0 {
+			return nil, fmt.Errorf("weighted fingerprint strategy requires at least one positive weight")
+		}
+	}
+
+	return sel, nil
+}
+
+// Select returns the fingerprint to use for a request targeting host.
+func (s *FingerprintSelector) Select(host string) Fingerprint {
+	if fp, ok := s.pinned(host); ok {
+		return fp
+	}
+
+	switch s.strategy {
+	case StrategyRandomPerRequest:
+		return s.fingerprints[rand.Intn(len(s.fingerprints))]
+	case StrategyRoundRobin:
+		return s.roundRobin()
+	case StrategyWeighted:
+		return s.weighted()
+	case StrategyStickyPerHost:
+		return s.stickyPick(host)
+	default: // StrategyFixed
+		return s.fixed
+	}
+}
+
+// Pin forces host to always use the fingerprint named fpName, overriding the
+// configured strategy, until Unpin is called.
+func (s *FingerprintSelector) Pin(host, fpName string) error {
+	for _, fp := range s.fingerprints {
+		if strings.EqualFold(fp.Name, fpName) {
+			s.pinsMu.Lock()
+			s.pins[host] = fp
+			s.pinsMu.Unlock()
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown fingerprint %q", fpName)
+}
+
+// Unpin removes any pin set for host, letting it fall back to the
+// configured strategy again.
+func (s *FingerprintSelector) Unpin(host string) {
+	s.pinsMu.Lock()
+	delete(s.pins, host)
+	s.pinsMu.Unlock()
+}
+
+func (s *FingerprintSelector) pinned(host string) (Fingerprint, bool) {
+	s.pinsMu.RLock()
+	defer s.pinsMu.RUnlock()
+	fp, ok := s.pins[host]
+	return fp, ok
+}
+
+func (s *FingerprintSelector) roundRobin() Fingerprint {
+	n := atomic.AddUint64(&s.rrCounter, 1) - 1
+	return s.fingerprints[int(n%uint64(len(s.fingerprints)))]
+}
+
+func (s *FingerprintSelector) weighted() Fingerprint {
+	total := 0
+	for _, w := range s.weights {
+		total += w
+	}
+	r := rand.Intn(total)
+	for i, w := range s.weights {
+		if r < w {
+			return s.fingerprints[i]
+		}
+		r -= w
+	}
+	return s.fingerprints[len(s.fingerprints)-1]
+}
+
+// stickyPick returns host's previously chosen fingerprint, or picks and
+// caches a new one if host hasn't been seen before, evicting the least
+// recently used host once the LRU exceeds defaultStickyLRUSize entries.
+func (s *FingerprintSelector) stickyPick(host string) Fingerprint {
+	s.stickyMu.Lock()
+	defer s.stickyMu.Unlock()
+
+	if el, ok := s.stickyM[host]; ok {
+		s.sticky.MoveToFront(el)
+		return el.Value.(*stickyEntry).fp
+	}
+
+	fp := s.pickForNewHost()
+	el := s.sticky.PushFront(&stickyEntry{host: host, fp: fp})
+	s.stickyM[host] = el
+
+	if s.sticky.Len() > defaultStickyLRUSize {
+		oldest := s.sticky.Back()
+		if oldest != nil {
+			s.sticky.Remove(oldest)
+			delete(s.stickyM, oldest.Value.(*stickyEntry).host)
+		}
+	}
+
+	return fp
+}
+
+// pickForNewHost chooses a fingerprint for a host seen for the first time by
+// sticky-per-host: weighted selection if s.weights is set, otherwise a
+// uniform random pick. NewFingerprintSelector sets s.weights only for
+// StrategyWeighted, so under sticky-per-host the pick is uniform.
+func (s *FingerprintSelector) pickForNewHost() Fingerprint {
+	if len(s.weights) > 0 {
+		return s.weighted()
+	}
+	return s.fingerprints[rand.Intn(len(s.fingerprints))]
+}
@@ -2,6 +2,7 @@ package transport
 
 import (
 	tls "github.com/refraction-networking/utls"
+	"golang.org/x/net/http2"
 )
 
 // Fingerprint represents a browser TLS fingerprint
@@ -9,6 +10,50 @@ type Fingerprint struct {
 	ID        tls.ClientHelloID
 	Name      string
 	UserAgent string
+
+	// Spec, if set, is applied with uConn.ApplyPreset instead of selecting
+	// one of uTLS's built-in ID presets, letting callers pin an exact
+	// ClientHelloSpec (cipher/extension order, supported groups, signature
+	// algorithms, ALPN list, GREASE placement). See LoadFingerprintsFromFile.
+	Spec *tls.ClientHelloSpec
+
+	// H2, if set, is the HTTP/2 connection-preface fingerprint applied right
+	// after the uTLS handshake negotiates h2 (see utlsRoundTripper.newH2ClientConn).
+	// A nil H2 falls back to http2.Transport's own default preface.
+	H2 *H2Fingerprint
+}
+
+// H2Fingerprint describes the HTTP/2 connection-preface fingerprint sent
+// immediately after the uTLS handshake, matching a specific browser's
+// SETTINGS values, initial connection-level WINDOW_UPDATE, HEADERS
+// pseudo-header order, and (for Chrome) the PRIORITY frames it sends right
+// after the preface.
+type H2Fingerprint struct {
+	// Settings are sent, in order, in the connection's first SETTINGS frame.
+	Settings []http2.Setting
+
+	// ConnectionFlow is the increment sent in the connection-level (stream 0)
+	// WINDOW_UPDATE frame that follows SETTINGS. Zero skips that frame.
+	ConnectionFlow uint32
+
+	// HeaderOrder records the browser's pseudo-header order, e.g.
+	// [":method", ":authority", ":scheme", ":path"]. It is not applied to
+	// the wire: HEADERS frames are written by http2.Transport in its own
+	// pseudo-header order, whatever HeaderOrder holds.
+	HeaderOrder []string
+
+	// PriorityFrames are optional PRIORITY frames sent right after SETTINGS,
+	// matching a browser's fixed stream-priority tree (Chrome and Firefox
+	// both send these; Safari doesn't).
+	PriorityFrames []PriorityParam
+}
+
+// PriorityParam describes a single HTTP/2 PRIORITY frame.
+type PriorityParam struct {
+	StreamID  uint32
+	StreamDep uint32
+	Exclusive bool
+	Weight    uint8
 }
 
 // GetFingerprints returns a list of available browser fingerprints
@@ -18,17 +63,83 @@ func GetFingerprints() []Fingerprint {
 			ID:        tls.HelloChrome_Auto,
 			Name:      "Chrome",
 			UserAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			H2:        chromeH2Fingerprint(),
 		},
 		{
 			ID:        tls.HelloFirefox_Auto,
 			Name:      "Firefox",
 			UserAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:121.0) Gecko/20100101 Firefox/121.0",
+			H2:        firefoxH2Fingerprint(),
 		},
 		{
 			ID:        tls.HelloIOS_Auto,
 			Name:      "Safari",
 			UserAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1",
+			H2:        safariH2Fingerprint(),
+		},
+	}
+}
+
+// chromeH2Fingerprint matches Chrome's real HTTP/2 preface: SETTINGS
+// HEADER_TABLE_SIZE=65536, ENABLE_PUSH=0, INITIAL_WINDOW_SIZE=6291456,
+// MAX_HEADER_LIST_SIZE=262144, followed by a 15663105-byte connection
+// WINDOW_UPDATE and Chrome's fixed PRIORITY stream tree.
+func chromeH2Fingerprint() *H2Fingerprint {
+	return &H2Fingerprint{
+		Settings: []http2.Setting{
+			{ID: http2.SettingHeaderTableSize, Val: 65536},
+			{ID: http2.SettingEnablePush, Val: 0},
+			{ID: http2.SettingInitialWindowSize, Val: 6291456},
+			{ID: http2.SettingMaxHeaderListSize, Val: 262144},
+		},
+		ConnectionFlow: 15663105,
+		HeaderOrder:    []string{":method", ":authority", ":scheme", ":path"},
+		PriorityFrames: []PriorityParam{
+			{StreamID: 3, StreamDep: 0, Exclusive: false, Weight: 200},
+			{StreamID: 5, StreamDep: 0, Exclusive: false, Weight: 100},
+			{StreamID: 7, StreamDep: 0, Exclusive: false, Weight: 0},
+			{StreamID: 9, StreamDep: 7, Exclusive: false, Weight: 0},
+			{StreamID: 11, StreamDep: 3, Exclusive: false, Weight: 0},
+		},
+	}
+}
+
+// firefoxH2Fingerprint matches Firefox's real HTTP/2 preface: SETTINGS
+// HEADER_TABLE_SIZE=65536, INITIAL_WINDOW_SIZE=131072, MAX_FRAME_SIZE=16384,
+// followed by a 12517377-byte connection WINDOW_UPDATE and Firefox's fixed
+// PRIORITY stream tree.
+func firefoxH2Fingerprint() *H2Fingerprint {
+	return &H2Fingerprint{
+		Settings: []http2.Setting{
+			{ID: http2.SettingHeaderTableSize, Val: 65536},
+			{ID: http2.SettingInitialWindowSize, Val: 131072},
+			{ID: http2.SettingMaxFrameSize, Val: 16384},
+		},
+		ConnectionFlow: 12517377,
+		HeaderOrder:    []string{":method", ":path", ":authority", ":scheme"},
+		PriorityFrames: []PriorityParam{
+			{StreamID: 3, StreamDep: 0, Exclusive: false, Weight: 200},
+			{StreamID: 5, StreamDep: 0, Exclusive: false, Weight: 100},
+			{StreamID: 7, StreamDep: 0, Exclusive: false, Weight: 0},
+			{StreamID: 9, StreamDep: 7, Exclusive: false, Weight: 0},
+			{StreamID: 11, StreamDep: 3, Exclusive: false, Weight: 0},
+			{StreamID: 13, StreamDep: 0, Exclusive: false, Weight: 240},
+		},
+	}
+}
+
+// safariH2Fingerprint matches Safari/iOS's real HTTP/2 preface: SETTINGS
+// INITIAL_WINDOW_SIZE=4194304, MAX_CONCURRENT_STREAMS=100, followed by a
+// 10485760-byte connection WINDOW_UPDATE. Safari doesn't send PRIORITY
+// frames after the preface.
+func safariH2Fingerprint() *H2Fingerprint {
+	return &H2Fingerprint{
+		Settings: []http2.Setting{
+			{ID: http2.SettingInitialWindowSize, Val: 4194304},
+			{ID: http2.SettingMaxConcurrentStreams, Val: 100},
 		},
+		ConnectionFlow: 10485760,
+		HeaderOrder:    []string{":method", ":scheme", ":path", ":authority"},
 	}
 }
 
@@ -0,0 +1,234 @@
+package transport
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	utls "github.com/refraction-networking/utls"
+	"golang.org/x/net/http2"
+)
+
+// Defaults for h2ConnPool, used when Config doesn't override them.
+const (
+	defaultMaxConnsPerHost = 4
+	defaultH2IdleTimeout   = 90 * time.Second
+	defaultH2ReapInterval  = 30 * time.Second
+)
+
+// h2PoolKey identifies a pooled HTTP/2 connection by target address
+// (host:port) and negotiated ALPN protocol.
+type h2PoolKey struct {
+	addr string
+	alpn string
+}
+
+// h2DialResult is what a coalesced dial delivers to every waiter through a
+// pending channel. h2Conn is nil and isH1 is true when the dial negotiated
+// http/1.1 instead of h2 — that connection is winner-private (h1 can't be
+// shared across requests), so waiters in that case must dial their own.
+type h2DialResult struct {
+	h2Conn *http2.ClientConn
+	isH1   bool
+	err    error
+}
+
+// h2ConnPool reuses *http2.ClientConn across requests to the same
+// h2PoolKey. A connection's CanTakeNewRequest check and its handout happen
+// atomically under p.mu, closing the race where a concurrent RoundTrip
+// could delete or close a connection in between. Concurrent callers for a
+// key with no usable connection yet coalesce onto a single in-flight dial
+// instead of racing to open MaxConnsPerHost+1 sockets. A background reaper
+// closes connections that have sat idle past IdleConnTimeout.
+type h2ConnPool struct {
+	mu      sync.Mutex
+	conns   map[h2PoolKey][]*http2.ClientConn
+	pending map[h2PoolKey][]chan h2DialResult
+
+	maxConnsPerHost int
+	idleConnTimeout time.Duration
+
+	stopOnce sync.Once
+	stopCh   chan struct{}
+}
+
+// newH2ConnPool creates a pool and starts its background idle-connection
+// reaper; callers must call stop() once they're done with the pool (see
+// UTLSClient.Close).
+func newH2ConnPool(maxConnsPerHost int, idleConnTimeout time.Duration) *h2ConnPool {
+	if maxConnsPerHost <= 0 {
+		maxConnsPerHost = defaultMaxConnsPerHost
+	}
+	if idleConnTimeout <= 0 {
+		idleConnTimeout = defaultH2IdleTimeout
+	}
+
+	p := &h2ConnPool{
+		conns:           make(map[h2PoolKey][]*http2.ClientConn),
+		pending:         make(map[h2PoolKey][]chan h2DialResult),
+		maxConnsPerHost: maxConnsPerHost,
+		idleConnTimeout: idleConnTimeout,
+		stopCh:          make(chan struct{}),
+	}
+	go p.reapLoop()
+	return p
+}
+
+// get returns an existing pooled connection for key that can still take a
+// new request, or nil.
+func (p *h2ConnPool) get(key h2PoolKey) *http2.ClientConn {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.pick(key)
+}
+
+// pick returns a usable pooled connection for key. Callers must hold p.mu.
+func (p *h2ConnPool) pick(key h2PoolKey) *http2.ClientConn {
+	conns := p.conns[key]
+	for i := len(conns) - 1; i >= 0; i-- {
+		if conns[i].CanTakeNewRequest() {
+			return conns[i]
+		}
+	}
+	return nil
+}
+
+// getOrDial returns a usable connection for key: an existing pooled h2
+// connection, or the result of dial if none is available. Concurrent
+// callers for the same key while a dial is already in flight wait for and
+// share that dial's result — unless it turns out to negotiate h1, in which
+// case mustDialOwn is true and the caller must dial its own connection,
+// since an h1 connection can't be shared.
+func (p *h2ConnPool) getOrDial(
+	ctx context.Context,
+	key h2PoolKey,
+	dial func(ctx context.Context) (h2Conn *http2.ClientConn, h1Conn *utls.UConn, err error),
+) (h2Conn *http2.ClientConn, h1Conn *utls.UConn, mustDialOwn bool, err error) {
+	p.mu.Lock()
+	if conn := p.pick(key); conn != nil {
+		p.mu.Unlock()
+		return conn, nil, false, nil
+	}
+
+	if waiters, ok := p.pending[key]; ok {
+		ch := make(chan h2DialResult, 1)
+		p.pending[key] = append(waiters, ch)
+		p.mu.Unlock()
+
+		select {
+		case res := <-ch:
+			if res.err != nil {
+				return nil, nil, false, res.err
+			}
+			if res.isH1 {
+				return nil, nil, true, nil
+			}
+			return res.h2Conn, nil, false, nil
+		case <-ctx.Done():
+			return nil, nil, false, ctx.Err()
+		}
+	}
+
+	p.pending[key] = nil
+	p.mu.Unlock()
+
+	h2Conn, h1Conn, err = dial(ctx)
+
+	p.mu.Lock()
+	waiters := p.pending[key]
+	delete(p.pending, key)
+	if err == nil && h2Conn != nil {
+		p.conns[key] = append(p.conns[key], h2Conn)
+		p.evictOverflowLocked(key)
+	}
+	p.mu.Unlock()
+
+	isH1 := err == nil && h2Conn == nil
+	for _, ch := range waiters {
+		ch <- h2DialResult{h2Conn: h2Conn, isH1: isH1, err: err}
+	}
+
+	return h2Conn, h1Conn, false, err
+}
+
+// evictOverflowLocked closes and removes the oldest pooled connections for
+// key once it holds more than maxConnsPerHost. Callers must hold p.mu.
+func (p *h2ConnPool) evictOverflowLocked(key h2PoolKey) {
+	conns := p.conns[key]
+	for len(conns) > p.maxConnsPerHost {
+		conns[0].Close()
+		conns = conns[1:]
+	}
+	p.conns[key] = conns
+}
+
+// remove deletes conn from key's pool, e.g. after a RoundTrip error or
+// GOAWAY, so the next request dials a fresh connection instead of reusing
+// a known-bad one.
+func (p *h2ConnPool) remove(key h2PoolKey, conn *http2.ClientConn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	conns := p.conns[key]
+	for i, c := range conns {
+		if c == conn {
+			p.conns[key] = append(conns[:i], conns[i+1:]...)
+			return
+		}
+	}
+}
+
+// reapLoop periodically closes connections idle past idleConnTimeout, until
+// stop is called.
+func (p *h2ConnPool) reapLoop() {
+	ticker := time.NewTicker(defaultH2ReapInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.reapIdle()
+		case <-p.stopCh:
+			return
+		}
+	}
+}
+
+// reapIdle closes and removes pooled connections that have had no active
+// streams for longer than idleConnTimeout.
+func (p *h2ConnPool) reapIdle() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for key, conns := range p.conns {
+		kept := conns[:0]
+		for _, c := range conns {
+			state := c.State()
+			if state.Closed || state.Closing {
+				continue
+			}
+			if state.StreamsActive == 0 && time.Since(state.LastIdle) > p.idleConnTimeout {
+				c.Close()
+				continue
+			}
+			kept = append(kept, c)
+		}
+		if len(kept) == 0 {
+			delete(p.conns, key)
+		} else {
+			p.conns[key] = kept
+		}
+	}
+}
+
+// stop halts the background reaper and closes every pooled connection.
+func (p *h2ConnPool) stop() {
+	p.stopOnce.Do(func() { close(p.stopCh) })
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key, conns := range p.conns {
+		for _, c := range conns {
+			c.Close()
+		}
+		delete(p.conns, key)
+	}
+}
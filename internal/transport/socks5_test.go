@@ -0,0 +1,209 @@
+package transport
+
+import (
+	"io"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// socks5Stub is a minimal in-process SOCKS5 server used to exercise
+// socks5Handshake without a real proxy. It accepts one connection, replies
+// to the greeting according to requireAuth, validates credentials when
+// requireAuth is set, and records the CONNECT request's ATYP and address.
+type socks5Stub struct {
+	ln          net.Listener
+	requireAuth bool
+	user, pass  string
+
+	gotATYP byte
+	gotAddr string
+}
+
+func newSOCKS5Stub(t *testing.T, requireAuth bool, user, pass string) *socks5Stub {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start socks5 stub: %v", err)
+	}
+	s := &socks5Stub{ln: ln, requireAuth: requireAuth, user: user, pass: pass}
+	go s.serveOne(t)
+	return s
+}
+
+func (s *socks5Stub) addr() string {
+	return s.ln.Addr().String()
+}
+
+func (s *socks5Stub) close() {
+	s.ln.Close()
+}
+
+func (s *socks5Stub) serveOne(t *testing.T) {
+	conn, err := s.ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	// Greeting: VER, NMETHODS, METHODS...
+	head := make([]byte, 2)
+	if _, err := io.ReadFull(conn, head); err != nil {
+		return
+	}
+	methods := make([]byte, head[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return
+	}
+
+	if s.requireAuth {
+		conn.Write([]byte{0x05, 0x02})
+		if err := s.checkAuth(conn); err != nil {
+			return
+		}
+	} else {
+		conn.Write([]byte{0x05, 0x00})
+	}
+
+	if err := s.readConnect(conn); err != nil {
+		return
+	}
+
+	// Reply: success, bound address 0.0.0.0:0
+	conn.Write([]byte{0x05, 0x00, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+}
+
+func (s *socks5Stub) checkAuth(conn net.Conn) error {
+	head := make([]byte, 2)
+	if _, err := io.ReadFull(conn, head); err != nil {
+		return err
+	}
+	username := make([]byte, head[1])
+	if _, err := io.ReadFull(conn, username); err != nil {
+		return err
+	}
+	plen := make([]byte, 1)
+	if _, err := io.ReadFull(conn, plen); err != nil {
+		return err
+	}
+	password := make([]byte, plen[0])
+	if _, err := io.ReadFull(conn, password); err != nil {
+		return err
+	}
+
+	if string(username) == s.user && string(password) == s.pass {
+		conn.Write([]byte{0x01, 0x00})
+		return nil
+	}
+	conn.Write([]byte{0x01, 0x01})
+	return io.ErrUnexpectedEOF
+}
+
+func (s *socks5Stub) readConnect(conn net.Conn) error {
+	head := make([]byte, 4)
+	if _, err := io.ReadFull(conn, head); err != nil {
+		return err
+	}
+	s.gotATYP = head[3]
+
+	switch head[3] {
+	case 0x01:
+		addr := make([]byte, net.IPv4len+2)
+		if _, err := io.ReadFull(conn, addr); err != nil {
+			return err
+		}
+		s.gotAddr = net.IP(addr[:net.IPv4len]).String()
+	case 0x03:
+		lenBuf := make([]byte, 1)
+		if _, err := io.ReadFull(conn, lenBuf); err != nil {
+			return err
+		}
+		rest := make([]byte, int(lenBuf[0])+2)
+		if _, err := io.ReadFull(conn, rest); err != nil {
+			return err
+		}
+		s.gotAddr = string(rest[:lenBuf[0]])
+	case 0x04:
+		addr := make([]byte, net.IPv6len+2)
+		if _, err := io.ReadFull(conn, addr); err != nil {
+			return err
+		}
+		s.gotAddr = net.IP(addr[:net.IPv6len]).String()
+	}
+
+	return nil
+}
+
+func TestSOCKS5HandshakeNoAuth(t *testing.T) {
+	stub := newSOCKS5Stub(t, false, "", "")
+	defer stub.close()
+
+	conn, err := net.DialTimeout("tcp", stub.addr(), 2*time.Second)
+	if err != nil {
+		t.Fatalf("dial stub: %v", err)
+	}
+	defer conn.Close()
+
+	proxyURL, _ := url.Parse("socks5://" + stub.addr())
+	if err := socks5Handshake(conn, proxyURL, "example.com:443", false); err != nil {
+		t.Fatalf("socks5Handshake failed: %v", err)
+	}
+	if stub.gotATYP != 0x03 {
+		t.Fatalf("expected domain ATYP for socks5h-style resolution, got %d", stub.gotATYP)
+	}
+	if stub.gotAddr != "example.com" {
+		t.Fatalf("expected target host example.com, got %q", stub.gotAddr)
+	}
+}
+
+func TestSOCKS5HandshakeResolvesLocally(t *testing.T) {
+	stub := newSOCKS5Stub(t, false, "", "")
+	defer stub.close()
+
+	conn, err := net.DialTimeout("tcp", stub.addr(), 2*time.Second)
+	if err != nil {
+		t.Fatalf("dial stub: %v", err)
+	}
+	defer conn.Close()
+
+	proxyURL, _ := url.Parse("socks5://" + stub.addr())
+	if err := socks5Handshake(conn, proxyURL, "127.0.0.1:443", true); err != nil {
+		t.Fatalf("socks5Handshake failed: %v", err)
+	}
+	if stub.gotATYP != 0x01 {
+		t.Fatalf("expected IPv4 ATYP when resolving locally, got %d", stub.gotATYP)
+	}
+}
+
+func TestSOCKS5HandshakeWithAuth(t *testing.T) {
+	stub := newSOCKS5Stub(t, true, "alice", "s3cret")
+	defer stub.close()
+
+	conn, err := net.DialTimeout("tcp", stub.addr(), 2*time.Second)
+	if err != nil {
+		t.Fatalf("dial stub: %v", err)
+	}
+	defer conn.Close()
+
+	proxyURL, _ := url.Parse("socks5://alice:s3cret@" + stub.addr())
+	if err := socks5Handshake(conn, proxyURL, "example.com:443", false); err != nil {
+		t.Fatalf("socks5Handshake with auth failed: %v", err)
+	}
+}
+
+func TestSOCKS5HandshakeAuthRejected(t *testing.T) {
+	stub := newSOCKS5Stub(t, true, "alice", "s3cret")
+	defer stub.close()
+
+	conn, err := net.DialTimeout("tcp", stub.addr(), 2*time.Second)
+	if err != nil {
+		t.Fatalf("dial stub: %v", err)
+	}
+	defer conn.Close()
+
+	proxyURL, _ := url.Parse("socks5://alice:wrong@" + stub.addr())
+	if err := socks5Handshake(conn, proxyURL, "example.com:443", false); err == nil {
+		t.Fatalf("expected socks5Handshake to fail with rejected credentials")
+	}
+}
@@ -0,0 +1,13 @@
+package captcha
+
+// AntiCaptchaSolver solves challenges via the Anti-Captcha task API.
+type AntiCaptchaSolver struct {
+	*restAPISolver
+}
+
+// NewAntiCaptchaSolver creates a new Anti-Captcha-backed solver.
+func NewAntiCaptchaSolver(apiKey string) *AntiCaptchaSolver {
+	return &AntiCaptchaSolver{
+		restAPISolver: newRestAPISolver("anti-captcha", "https://api.anti-captcha.com", apiKey),
+	}
+}
@@ -0,0 +1,14 @@
+package captcha
+
+// CapMonsterSolver solves challenges via the CapMonster Cloud task API,
+// which mirrors the Anti-Captcha request/response shape.
+type CapMonsterSolver struct {
+	*restAPISolver
+}
+
+// NewCapMonsterSolver creates a new CapMonster Cloud-backed solver.
+func NewCapMonsterSolver(apiKey string) *CapMonsterSolver {
+	return &CapMonsterSolver{
+		restAPISolver: newRestAPISolver("capmonster", "https://api.capmonster.cloud", apiKey),
+	}
+}
@@ -0,0 +1,122 @@
+package captcha
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// PendingChallenge is a snapshot of a challenge parked for a human operator
+// to solve out of band.
+type PendingChallenge struct {
+	ID        string    `json:"id"`
+	Challenge Challenge `json:"challenge"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type solveResult struct {
+	solution Solution
+	err      error
+}
+
+type pendingEntry struct {
+	challenge Challenge
+	createdAt time.Time
+	result    chan solveResult
+}
+
+// ManualSolver parks challenges in memory until a human operator submits a
+// solution via Submit, rather than calling out to a paid API.
+type ManualSolver struct {
+	mu      sync.Mutex
+	pending map[string]*pendingEntry
+}
+
+// NewManualSolver creates an empty ManualSolver.
+func NewManualSolver() *ManualSolver {
+	return &ManualSolver{
+		pending: make(map[string]*pendingEntry),
+	}
+}
+
+// Solve implements Solver: it parks challenge and blocks until Submit is
+// called with its ID, or ctx is cancelled.
+func (m *ManualSolver) Solve(ctx context.Context, challenge Challenge) (Solution, error) {
+	id := generatePendingID()
+	entry := &pendingEntry{
+		challenge: challenge,
+		createdAt: time.Now(),
+		result:    make(chan solveResult, 1),
+	}
+
+	m.mu.Lock()
+	m.pending[id] = entry
+	m.mu.Unlock()
+
+	defer func() {
+		m.mu.Lock()
+		delete(m.pending, id)
+		m.mu.Unlock()
+	}()
+
+	select {
+	case res := <-entry.result:
+		return res.solution, res.err
+	case <-ctx.Done():
+		return Solution{}, ctx.Err()
+	}
+}
+
+// Balance implements Solver. Manual solving has no account balance.
+func (m *ManualSolver) Balance() (float64, error) {
+	return 0, nil
+}
+
+// Name identifies this solver for metrics/logging.
+func (m *ManualSolver) Name() string {
+	return "manual"
+}
+
+// Pending lists every challenge currently parked for an operator.
+func (m *ManualSolver) Pending() []PendingChallenge {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list := make([]PendingChallenge, 0, len(m.pending))
+	for id, entry := range m.pending {
+		list = append(list, PendingChallenge{
+			ID:        id,
+			Challenge: entry.challenge,
+			CreatedAt: entry.createdAt,
+		})
+	}
+	return list
+}
+
+// Submit delivers a human-provided token for a still-pending challenge.
+// Returns false if id is unknown.
+func (m *ManualSolver) Submit(id, token string) bool {
+	m.mu.Lock()
+	entry, ok := m.pending[id]
+	m.mu.Unlock()
+	if !ok {
+		return false
+	}
+
+	entry.result <- solveResult{solution: Solution{
+		Token:    token,
+		SolvedIn: time.Since(entry.createdAt),
+	}}
+	return true
+}
+
+func generatePendingID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic("crypto/rand failed: " + err.Error())
+	}
+	return fmt.Sprintf("cap_%s", hex.EncodeToString(b))
+}
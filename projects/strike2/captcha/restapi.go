@@ -0,0 +1,178 @@
+package captcha
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// restAPISolver implements the Anti-Captcha / CapMonster Cloud task API,
+// which both providers expose with the same createTask / getTaskResult /
+// getBalance shape.
+type restAPISolver struct {
+	name       string
+	baseURL    string
+	clientKey  string
+	httpClient *http.Client
+}
+
+func newRestAPISolver(name, baseURL, clientKey string) *restAPISolver {
+	return &restAPISolver{
+		name:      name,
+		baseURL:   baseURL,
+		clientKey: clientKey,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+func (s *restAPISolver) taskPayload(challenge Challenge) (map[string]interface{}, string, error) {
+	switch challenge.Type {
+	case ChallengeRecaptchaV2:
+		return map[string]interface{}{
+			"type":       "NoCaptchaTaskProxyless",
+			"websiteURL": challenge.URL,
+			"websiteKey": challenge.SiteKey,
+		}, "gRecaptchaResponse", nil
+	case ChallengeRecaptchaV3:
+		return map[string]interface{}{
+			"type":       "RecaptchaV3TaskProxyless",
+			"websiteURL": challenge.URL,
+			"websiteKey": challenge.SiteKey,
+			"minScore":   challenge.MinScore,
+			"pageAction": challenge.Action,
+		}, "gRecaptchaResponse", nil
+	case ChallengeHCaptcha:
+		return map[string]interface{}{
+			"type":       "HCaptchaTaskProxyless",
+			"websiteURL": challenge.URL,
+			"websiteKey": challenge.SiteKey,
+		}, "gRecaptchaResponse", nil
+	case ChallengeCloudflareTurnstile:
+		return map[string]interface{}{
+			"type":       "TurnstileTaskProxyless",
+			"websiteURL": challenge.URL,
+			"websiteKey": challenge.SiteKey,
+		}, "token", nil
+	case ChallengeGeeTest:
+		return map[string]interface{}{
+			"type":       "GeeTestTaskProxyless",
+			"websiteURL": challenge.URL,
+			"gt":         challenge.Gt,
+			"challenge":  challenge.Challenge,
+		}, "challenge", nil
+	default:
+		return nil, "", fmt.Errorf("%s: unsupported challenge type %q", s.name, challenge.Type)
+	}
+}
+
+// Solve implements Solver.
+func (s *restAPISolver) Solve(ctx context.Context, challenge Challenge) (Solution, error) {
+	start := time.Now()
+
+	task, solutionField, err := s.taskPayload(challenge)
+	if err != nil {
+		return Solution{}, err
+	}
+
+	var createResp struct {
+		ErrorID          int    `json:"errorId"`
+		ErrorDescription string `json:"errorDescription"`
+		TaskID           int64  `json:"taskId"`
+	}
+	if err := s.post(ctx, "/createTask", map[string]interface{}{
+		"clientKey": s.clientKey,
+		"task":      task,
+	}, &createResp); err != nil {
+		return Solution{}, fmt.Errorf("%s: createTask failed: %w", s.name, err)
+	}
+	if createResp.ErrorID != 0 {
+		return Solution{}, fmt.Errorf("%s: createTask error: %s", s.name, createResp.ErrorDescription)
+	}
+
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return Solution{}, ctx.Err()
+		case <-ticker.C:
+		}
+
+		var resultResp struct {
+			ErrorID          int                    `json:"errorId"`
+			ErrorDescription string                 `json:"errorDescription"`
+			Status           string                 `json:"status"`
+			Solution         map[string]interface{} `json:"solution"`
+			Cost             string                 `json:"cost"`
+		}
+		if err := s.post(ctx, "/getTaskResult", map[string]interface{}{
+			"clientKey": s.clientKey,
+			"taskId":    createResp.TaskID,
+		}, &resultResp); err != nil {
+			return Solution{}, fmt.Errorf("%s: getTaskResult failed: %w", s.name, err)
+		}
+		if resultResp.ErrorID != 0 {
+			return Solution{}, fmt.Errorf("%s: getTaskResult error: %s", s.name, resultResp.ErrorDescription)
+		}
+		if resultResp.Status != "ready" {
+			continue
+		}
+
+		token, _ := resultResp.Solution[solutionField].(string)
+		return Solution{
+			Token:    token,
+			SolvedIn: time.Since(start),
+			Cost:     resultResp.Cost,
+		}, nil
+	}
+}
+
+// Name identifies this solver for metrics/logging.
+func (s *restAPISolver) Name() string {
+	return s.name
+}
+
+// Balance implements Solver.
+func (s *restAPISolver) Balance() (float64, error) {
+	var resp struct {
+		ErrorID          int     `json:"errorId"`
+		ErrorDescription string  `json:"errorDescription"`
+		Balance          float64 `json:"balance"`
+	}
+	if err := s.post(context.Background(), "/getBalance", map[string]interface{}{
+		"clientKey": s.clientKey,
+	}, &resp); err != nil {
+		return 0, fmt.Errorf("%s: getBalance failed: %w", s.name, err)
+	}
+	if resp.ErrorID != 0 {
+		return 0, fmt.Errorf("%s: getBalance error: %s", s.name, resp.ErrorDescription)
+	}
+	return resp.Balance, nil
+}
+
+func (s *restAPISolver) post(ctx context.Context, path string, body interface{}, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -1,28 +1,67 @@
 package captcha
 
 import (
-	"fmt"
+	"context"
 	"time"
 
-	api2captcha "github.com/2captcha/2captcha-go"
+	"github.com/Goga74/platform2/internal/metrics"
 )
 
-// Solver wraps 2Captcha API for Amazon WAF captcha solving
-type Solver struct {
-	client *api2captcha.Client
+// ChallengeType identifies the kind of captcha a Solver is asked to solve.
+type ChallengeType string
+
+const (
+	ChallengeAmazonWAF           ChallengeType = "amazon_waf"
+	ChallengeHCaptcha            ChallengeType = "hcaptcha"
+	ChallengeRecaptchaV2         ChallengeType = "recaptcha_v2"
+	ChallengeRecaptchaV3         ChallengeType = "recaptcha_v3"
+	ChallengeCloudflareTurnstile ChallengeType = "turnstile"
+	ChallengeGeeTest             ChallengeType = "geetest"
+)
+
+// Challenge describes a captcha challenge to solve. Only the fields that
+// apply to Type are expected to be populated; the rest are left zero.
+type Challenge struct {
+	Type    ChallengeType `json:"type"`
+	URL     string        `json:"url"`
+	SiteKey string        `json:"sitekey,omitempty"`
+
+	// Amazon WAF
+	IV              string `json:"iv,omitempty"`
+	Context         string `json:"context,omitempty"`
+	ChallengeScript string `json:"challenge_script,omitempty"`
+	CaptchaScript   string `json:"captcha_script,omitempty"`
+
+	// reCAPTCHA v3
+	Action   string  `json:"action,omitempty"`
+	MinScore float64 `json:"min_score,omitempty"`
+
+	// GeeTest
+	Gt        string `json:"gt,omitempty"`
+	Challenge string `json:"challenge,omitempty"`
 }
 
-// NewSolver creates a new 2Captcha solver
-func NewSolver(apiKey string) *Solver {
-	client := api2captcha.NewClient(apiKey)
-	client.DefaultTimeout = 120 // seconds
-	client.PollingInterval = 5  // seconds
-	return &Solver{
-		client: client,
-	}
+// Solution is the result of solving a Challenge.
+type Solution struct {
+	Token    string        `json:"token"`
+	SolvedIn time.Duration `json:"solved_in"`
+	Cost     string        `json:"cost,omitempty"`
+}
+
+// Solver solves captcha challenges, either through a paid third-party API
+// or by parking them for a human operator.
+type Solver interface {
+	// Solve blocks until challenge is solved, ctx is cancelled, or the
+	// backend reports a permanent failure.
+	Solve(ctx context.Context, challenge Challenge) (Solution, error)
+	// Balance returns the remaining account balance, in USD, for backends
+	// that track one. Manual solvers return 0, nil.
+	Balance() (float64, error)
 }
 
-// AmazonWAFRequest represents parameters for Amazon WAF captcha
+// AmazonWAFRequest represents parameters for Amazon WAF captcha. Kept for
+// the original /solve/amazon-waf route; new integrations should build a
+// Challenge directly and call Solve.
 type AmazonWAFRequest struct {
 	SiteKey         string `json:"sitekey"`
 	URL             string `json:"url"`
@@ -39,34 +78,39 @@ type AmazonWAFResponse struct {
 	Cost     string        `json:"cost"`
 }
 
-// SolveAmazonWAF solves Amazon WAF captcha using 2Captcha
-func (s *Solver) SolveAmazonWAF(req AmazonWAFRequest) (*AmazonWAFResponse, error) {
-	startTime := time.Now()
-
-	cap := api2captcha.AmazonWAF{
-		SiteKey:         req.SiteKey,
-		Iv:              req.IV,
-		Context:         req.Context,
-		Url:             req.URL,
-		ChallengeScript: req.ChallengeScript,
-		CaptchaScript:   req.CaptchaScript,
+func (r AmazonWAFRequest) toChallenge() Challenge {
+	return Challenge{
+		Type:            ChallengeAmazonWAF,
+		URL:             r.URL,
+		SiteKey:         r.SiteKey,
+		IV:              r.IV,
+		Context:         r.Context,
+		ChallengeScript: r.ChallengeScript,
+		CaptchaScript:   r.CaptchaScript,
 	}
+}
 
-	code, _, err := s.client.Solve(cap.ToRequest())
+// SolveAmazonWAF solves an Amazon WAF challenge via s, keeping the
+// historical request/response shape used by the /solve/amazon-waf route.
+func SolveAmazonWAF(ctx context.Context, s Solver, req AmazonWAFRequest) (*AmazonWAFResponse, error) {
+	start := time.Now()
+	sol, err := s.Solve(ctx, req.toChallenge())
+	metrics.CaptchaSolveDurationSeconds.Observe(time.Since(start).Seconds(), providerName(s), string(ChallengeAmazonWAF))
 	if err != nil {
-		return nil, fmt.Errorf("2captcha solve failed: %w", err)
+		return nil, err
 	}
-
-	solvedIn := time.Since(startTime)
-
 	return &AmazonWAFResponse{
-		Token:    code,
-		SolvedIn: solvedIn,
-		Cost:     "~$2.99",
+		Token:    sol.Token,
+		SolvedIn: sol.SolvedIn,
+		Cost:     sol.Cost,
 	}, nil
 }
 
-// GetBalance returns current 2Captcha balance
-func (s *Solver) GetBalance() (float64, error) {
-	return s.client.GetBalance()
+// providerName returns s's provider name for metrics labeling, if it
+// implements the informal Name() string interface, or "unknown" otherwise.
+func providerName(s Solver) string {
+	if named, ok := s.(interface{ Name() string }); ok {
+		return named.Name()
+	}
+	return "unknown"
 }
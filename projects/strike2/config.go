@@ -3,17 +3,25 @@ package strike2
 import (
 	"os"
 	"strconv"
+	"strings"
 )
 
 // LoadConfig reads Strike2 configuration from environment variables.
 // All Strike2-specific variables use the STRIKE2_ prefix.
 func LoadConfig() Config {
 	return Config{
-		ProxyToken:    os.Getenv("STRIKE2_PROXY_TOKEN"),
-		CaptchaKey:    os.Getenv("STRIKE2_CAPTCHA_KEY"),
-		UpstreamProxy: os.Getenv("STRIKE2_UPSTREAM_PROXY"),
-		Fingerprint:   getEnv("FINGERPRINT", "chrome"),
-		Workers:       getEnvInt("WORKERS", 500),
+		ProxyToken:          os.Getenv("STRIKE2_PROXY_TOKEN"),
+		CaptchaProvider:     getEnv("STRIKE2_CAPTCHA_PROVIDER", "2captcha"),
+		CaptchaAPIKey:       os.Getenv("STRIKE2_CAPTCHA_KEY"),
+		AntiCaptchaKey:      os.Getenv("STRIKE2_ANTICAPTCHA_KEY"),
+		CapMonsterKey:       os.Getenv("STRIKE2_CAPMONSTER_KEY"),
+		UpstreamProxy:       os.Getenv("STRIKE2_UPSTREAM_PROXY"),
+		Fingerprint:         getEnv("FINGERPRINT", "chrome"),
+		FingerprintStrategy: getEnv("STRIKE2_FP_STRATEGY", "fixed"),
+		FingerprintWeights:  getEnvWeights("STRIKE2_FP_WEIGHTS"),
+		Workers:             getEnvInt("WORKERS", 500),
+		DatabaseURL:         os.Getenv("STRIKE2_DATABASE_URL"),
+		CacheTTLSeconds:     getEnvInt("STRIKE2_CACHE_TTL_SECONDS", 0),
 	}
 }
 
@@ -32,3 +40,31 @@ func getEnvInt(key string, fallback int) int {
 	}
 	return fallback
 }
+
+// getEnvWeights parses a comma-separated "name=weight" list (e.g.
+// "chrome=8,firefox=1,safari=1") used to configure the weighted fingerprint
+// strategy. Malformed entries are skipped. Returns nil if key is unset.
+func getEnvWeights(key string) map[string]int {
+	val := os.Getenv(key)
+	if val == "" {
+		return nil
+	}
+
+	weights := make(map[string]int)
+	for _, pair := range strings.Split(val, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, weightStr, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		weight, err := strconv.Atoi(strings.TrimSpace(weightStr))
+		if err != nil {
+			continue
+		}
+		weights[strings.ToLower(strings.TrimSpace(name))] = weight
+	}
+	return weights
+}
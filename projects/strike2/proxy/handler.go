@@ -3,94 +3,146 @@ package proxy
 import (
 	"bufio"
 	"context"
-	"encoding/base64"
 	"fmt"
 	"io"
 	"log"
 	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/Goga74/platform2/internal/metrics"
 	"github.com/Goga74/platform2/internal/transport"
+	"github.com/Goga74/platform2/projects/strike2/logging"
 )
 
 // Handler handles HTTP/HTTPS proxy requests with JA3 fingerprint spoofing
 type Handler struct {
-	config      *Config
-	fingerprint transport.Fingerprint
-	upstreamURL *url.URL
+	config        *Config
+	fpSelector    *transport.FingerprintSelector
+	upstreamChain []*url.URL
 }
 
-// NewHandler creates a new proxy handler
-func NewHandler(config *Config) (*Handler, error) {
+// NewHandler creates a new proxy handler. fpSelector picks the fingerprint
+// used for each request's target host according to the configured
+// FingerprintStrategy.
+func NewHandler(config *Config, fpSelector *transport.FingerprintSelector) (*Handler, error) {
 	h := &Handler{
-		config: config,
+		config:     config,
+		fpSelector: fpSelector,
 	}
 
-	// Set fingerprint (default to Chrome)
-	if config.Fingerprint != "" {
-		for _, fp := range transport.GetFingerprints() {
-			if strings.EqualFold(fp.Name, config.Fingerprint) {
-				h.fingerprint = fp
-				break
-			}
-		}
-	}
-	if h.fingerprint.Name == "" {
-		h.fingerprint = transport.GetRandomFingerprint()
-	}
-
-	// Parse upstream proxy if configured
+	// Parse upstream proxy chain if configured
 	if config.HasUpstreamProxy() {
-		var err error
-		h.upstreamURL, err = url.Parse(config.UpstreamProxy)
+		chain, err := config.ParseUpstreamProxy()
 		if err != nil {
-			return nil, fmt.Errorf("invalid upstream proxy URL: %w", err)
+			return nil, fmt.Errorf("invalid upstream proxy: %w", err)
 		}
-		log.Printf("[Proxy] Upstream proxy configured: %s", h.upstreamURL.Host)
+		h.upstreamChain = chain
+
+		hops := make([]string, len(chain))
+		for i, hop := range chain {
+			hops[i] = hop.Scheme + "://" + hop.Host
+		}
+		log.Printf("[Proxy] Upstream proxy chain configured: %s", strings.Join(hops, " -> "))
 	}
 
 	return h, nil
 }
 
+// hasUpstreamChain returns true if at least one upstream proxy hop is configured.
+func (h *Handler) hasUpstreamChain() bool {
+	return len(h.upstreamChain) > 0
+}
+
 // ServeHTTP handles incoming proxy requests
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	traceID := logging.TraceIDFromRequest(r)
+	w.Header().Set(logging.TraceHeader, traceID)
+
+	host := requestHost(r)
+	fp := h.fpSelector.Select(stripPort(host))
+
+	sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+
 	if r.Method == http.MethodConnect {
-		h.handleConnect(w, r)
+		h.handleConnect(sw, r, traceID, fp)
 	} else {
-		h.handleHTTP(w, r)
+		h.handleHTTP(sw, r, traceID, fp)
 	}
+
+	metrics.RequestsTotal.Inc(fp.Name, strconv.Itoa(sw.status), host)
+}
+
+// statusWriter tracks the status code written through it so ServeHTTP can
+// record it in requests_total after the handler returns; it also forwards
+// Hijack so handleConnect's type assertion against http.Hijacker keeps working.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sw *statusWriter) WriteHeader(status int) {
+	sw.status = status
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+func (sw *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return sw.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+// requestHost returns the host a proxy request targets, for both CONNECT
+// requests (r.Host) and absolute-URL plain HTTP requests (r.URL.Host).
+func requestHost(r *http.Request) string {
+	if r.Host != "" {
+		return r.Host
+	}
+	return r.URL.Host
+}
+
+// stripPort drops a trailing ":port" from host, so the fingerprint selector
+// sees the same bare hostname for a target regardless of the port used to
+// reach it.
+func stripPort(host string) string {
+	if i := strings.LastIndex(host, ":"); i != -1 {
+		return host[:i]
+	}
+	return host
 }
 
 // handleConnect handles HTTPS CONNECT tunneling
-func (h *Handler) handleConnect(w http.ResponseWriter, r *http.Request) {
-	log.Printf("[Proxy] CONNECT %s", r.Host)
+func (h *Handler) handleConnect(w http.ResponseWriter, r *http.Request, traceID string, fp transport.Fingerprint) {
+	logging.Default.Info("proxy.connect.start", logging.Fields{"trace_id": traceID, "host": r.Host})
 
 	targetAddr := r.Host
 	if !strings.Contains(targetAddr, ":") {
 		targetAddr += ":443"
 	}
 
-	client, err := transport.NewUTLSClient(h.fingerprint)
+	client, err := transport.NewUTLSClient(fp, transport.Config{})
 	if err != nil {
+		logging.Default.Error("proxy.connect.client_init_failed", logging.Fields{"trace_id": traceID, "error": err.Error()})
 		http.Error(w, "Failed to create TLS client", http.StatusInternalServerError)
 		return
 	}
+	defer client.Close()
 
 	var targetConn net.Conn
 	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
 	defer cancel()
 
-	if h.upstreamURL != nil {
-		targetConn, err = h.dialViaUpstreamProxy(ctx, targetAddr)
+	dialStart := time.Now()
+	if h.hasUpstreamChain() {
+		targetConn, err = h.dialChain(ctx, targetAddr)
 	} else {
 		targetConn, err = h.dialDirectTLS(ctx, targetAddr, client)
 	}
+	metrics.TLSHandshakeDurationSeconds.Observe(time.Since(dialStart).Seconds(), r.Host)
 
 	if err != nil {
-		log.Printf("[Proxy] CONNECT failed to %s: %v", targetAddr, err)
+		logging.Default.Error("proxy.connect.dial_failed", logging.Fields{"trace_id": traceID, "host": targetAddr, "error": err.Error()})
 		http.Error(w, fmt.Sprintf("Failed to connect: %v", err), http.StatusBadGateway)
 		return
 	}
@@ -111,6 +163,8 @@ func (h *Handler) handleConnect(w http.ResponseWriter, r *http.Request) {
 
 	clientConn.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n"))
 
+	logging.Default.Info("proxy.connect.established", logging.Fields{"trace_id": traceID, "host": targetAddr})
+
 	done := make(chan struct{}, 2)
 
 	go func() {
@@ -124,6 +178,8 @@ func (h *Handler) handleConnect(w http.ResponseWriter, r *http.Request) {
 	}()
 
 	<-done
+
+	logging.Default.Info("proxy.connect.closed", logging.Fields{"trace_id": traceID, "host": targetAddr})
 }
 
 // dialDirectTLS connects directly to target using raw TCP (client does TLS)
@@ -136,69 +192,87 @@ func (h *Handler) dialDirectTLS(ctx context.Context, addr string, client *transp
 	return dialer.DialContext(ctx, "tcp", addr)
 }
 
-// dialViaUpstreamProxy connects through upstream proxy
-func (h *Handler) dialViaUpstreamProxy(ctx context.Context, targetAddr string) (net.Conn, error) {
+// dialChain dials the first hop in the upstream proxy chain, then tunnels
+// through each subsequent hop in order (each layered over the previous
+// hop's tunnel) until it reaches targetAddr.
+func (h *Handler) dialChain(ctx context.Context, targetAddr string) (net.Conn, error) {
+	if !h.hasUpstreamChain() {
+		return nil, fmt.Errorf("no upstream proxy configured")
+	}
+
 	dialer := &net.Dialer{
 		Timeout:   15 * time.Second,
 		KeepAlive: 30 * time.Second,
 	}
 
-	proxyAddr := h.upstreamURL.Host
-	if !strings.Contains(proxyAddr, ":") {
-		proxyAddr += ":80"
-	}
-
-	conn, err := dialer.DialContext(ctx, "tcp", proxyAddr)
+	first := h.upstreamChain[0]
+	dialStart := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", hopAddr(first))
+	metrics.UpstreamDialDurationSeconds.Observe(time.Since(dialStart).Seconds(), first.Scheme)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to upstream proxy: %w", err)
+		return nil, fmt.Errorf("failed to connect to upstream proxy %s: %w", first.Host, err)
 	}
 
-	connectReq := fmt.Sprintf("CONNECT %s HTTP/1.1\r\nHost: %s\r\n", targetAddr, targetAddr)
+	for i, hop := range h.upstreamChain {
+		nextAddr := targetAddr
+		if i+1 < len(h.upstreamChain) {
+			nextAddr = hopAddr(h.upstreamChain[i+1])
+		}
 
-	if h.upstreamURL.User != nil {
-		auth := h.upstreamURL.User.String()
-		encoded := base64.StdEncoding.EncodeToString([]byte(auth))
-		connectReq += fmt.Sprintf("Proxy-Authorization: Basic %s\r\n", encoded)
+		if err := tunnelThroughHop(conn, hop, nextAddr); err != nil {
+			conn.Close()
+			return nil, err
+		}
 	}
 
-	connectReq += "\r\n"
+	return conn, nil
+}
 
-	_, err = conn.Write([]byte(connectReq))
-	if err != nil {
-		conn.Close()
-		return nil, fmt.Errorf("failed to send CONNECT to upstream: %w", err)
+// tunnelThroughHop negotiates hop's protocol over an already-connected conn
+// so that subsequent traffic on conn reaches nextAddr.
+func tunnelThroughHop(conn net.Conn, hop *url.URL, nextAddr string) error {
+	switch hop.Scheme {
+	case "http", "https":
+		return httpConnectHop(conn, hop, nextAddr)
+	case "socks5":
+		return socks5ConnectHop(conn, hop, nextAddr, true)
+	case "socks5h":
+		return socks5ConnectHop(conn, hop, nextAddr, false)
+	default:
+		return fmt.Errorf("unsupported upstream proxy scheme %q", hop.Scheme)
 	}
+}
 
-	reader := bufio.NewReader(conn)
-	resp, err := http.ReadResponse(reader, nil)
-	if err != nil {
-		conn.Close()
-		return nil, fmt.Errorf("failed to read upstream CONNECT response: %w", err)
+// hopAddr returns host:port for a proxy hop URL, defaulting to port 1080
+// for SOCKS5 hops and 80 for HTTP hops.
+func hopAddr(hop *url.URL) string {
+	if strings.Contains(hop.Host, ":") {
+		return hop.Host
 	}
-	resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		conn.Close()
-		return nil, fmt.Errorf("upstream proxy CONNECT failed: %s", resp.Status)
+	if hop.Scheme == "socks5" || hop.Scheme == "socks5h" {
+		return hop.Host + ":1080"
 	}
+	return hop.Host + ":80"
+}
 
-	return conn, nil
+// upstreamTransport returns an *http.Transport that dials plain-HTTP proxy
+// requests through the configured upstream chain.
+func (h *Handler) upstreamTransport() *http.Transport {
+	return &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return h.dialChain(ctx, addr)
+		},
+	}
 }
 
 // handleHTTP handles plain HTTP proxy requests
-func (h *Handler) handleHTTP(w http.ResponseWriter, r *http.Request) {
+func (h *Handler) handleHTTP(w http.ResponseWriter, r *http.Request, traceID string, fp transport.Fingerprint) {
 	if !r.URL.IsAbs() {
 		http.Error(w, "This is a proxy server. Send absolute URLs.", http.StatusBadRequest)
 		return
 	}
 
-	log.Printf("[Proxy] %s %s", r.Method, r.URL.String())
-
-	client, err := transport.NewUTLSClient(h.fingerprint)
-	if err != nil {
-		http.Error(w, "Failed to create client", http.StatusInternalServerError)
-		return
-	}
+	logging.Default.Info("proxy.http.start", logging.Fields{"trace_id": traceID, "method": r.Method, "url": r.URL.String()})
 
 	outReq, err := http.NewRequestWithContext(r.Context(), r.Method, r.URL.String(), r.Body)
 	if err != nil {
@@ -211,9 +285,21 @@ func (h *Handler) handleHTTP(w http.ResponseWriter, r *http.Request) {
 	outReq.Header.Del("Proxy-Connection")
 	outReq.Header.Del("Proxy-Authorization")
 
-	resp, err := client.Do(outReq)
+	var resp *http.Response
+	if h.hasUpstreamChain() {
+		resp, err = h.upstreamTransport().RoundTrip(outReq)
+	} else {
+		var client *transport.UTLSClient
+		client, err = transport.NewUTLSClient(fp, transport.Config{})
+		if err != nil {
+			http.Error(w, "Failed to create client", http.StatusInternalServerError)
+			return
+		}
+		defer client.Close()
+		resp, err = client.Do(outReq)
+	}
 	if err != nil {
-		log.Printf("[Proxy] Request failed: %v", err)
+		logging.Default.Error("proxy.http.request_failed", logging.Fields{"trace_id": traceID, "url": r.URL.String(), "error": err.Error()})
 		http.Error(w, fmt.Sprintf("Request failed: %v", err), http.StatusBadGateway)
 		return
 	}
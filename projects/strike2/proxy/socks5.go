@@ -0,0 +1,214 @@
+package proxy
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// httpConnectHop tunnels to targetAddr through an HTTP CONNECT proxy,
+// assuming conn is already a live connection to the hop itself (possibly
+// layered over earlier hops in a chain).
+func httpConnectHop(conn net.Conn, hop *url.URL, targetAddr string) error {
+	req := fmt.Sprintf("CONNECT %s HTTP/1.1\r\nHost: %s\r\n", targetAddr, targetAddr)
+
+	if hop.User != nil {
+		encoded := base64.StdEncoding.EncodeToString([]byte(hop.User.String()))
+		req += fmt.Sprintf("Proxy-Authorization: Basic %s\r\n", encoded)
+	}
+	req += "\r\n"
+
+	if _, err := conn.Write([]byte(req)); err != nil {
+		return fmt.Errorf("failed to send CONNECT to %s: %w", hop.Host, err)
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		return fmt.Errorf("failed to read CONNECT response from %s: %w", hop.Host, err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("upstream proxy %s CONNECT failed: %s", hop.Host, resp.Status)
+	}
+
+	return nil
+}
+
+// socks5ConnectHop performs a SOCKS5 handshake (RFC 1928) and CONNECT
+// command against a live connection to the hop, tunneling to targetAddr.
+// resolveLocally selects between the socks5:// (resolve locally, send an
+// IP ATYP) and socks5h:// (defer resolution to the proxy, send a domain
+// ATYP) behaviors.
+func socks5ConnectHop(conn net.Conn, hop *url.URL, targetAddr string, resolveLocally bool) error {
+	methods := []byte{0x00} // no auth
+	if hop.User != nil {
+		methods = []byte{0x02} // username/password, RFC 1929
+	}
+
+	greeting := append([]byte{0x05, byte(len(methods))}, methods...)
+	if _, err := conn.Write(greeting); err != nil {
+		return fmt.Errorf("socks5 greeting to %s failed: %w", hop.Host, err)
+	}
+
+	selected := make([]byte, 2)
+	if _, err := io.ReadFull(conn, selected); err != nil {
+		return fmt.Errorf("socks5 greeting response from %s failed: %w", hop.Host, err)
+	}
+	if selected[0] != 0x05 {
+		return fmt.Errorf("socks5 %s: unexpected version %d", hop.Host, selected[0])
+	}
+
+	switch selected[1] {
+	case 0x00:
+		// no auth required
+	case 0x02:
+		if hop.User == nil {
+			return fmt.Errorf("socks5 %s requires username/password auth", hop.Host)
+		}
+		if err := socks5Authenticate(conn, hop.User); err != nil {
+			return err
+		}
+	case 0xff:
+		return fmt.Errorf("socks5 %s rejected all authentication methods", hop.Host)
+	default:
+		return fmt.Errorf("socks5 %s selected unsupported auth method %d", hop.Host, selected[1])
+	}
+
+	return socks5Connect(conn, targetAddr, resolveLocally)
+}
+
+func socks5Authenticate(conn net.Conn, user *url.Userinfo) error {
+	username := user.Username()
+	password, _ := user.Password()
+	if len(username) > 255 || len(password) > 255 {
+		return fmt.Errorf("socks5 username/password exceeds 255 bytes")
+	}
+
+	req := []byte{0x01, byte(len(username))}
+	req = append(req, []byte(username)...)
+	req = append(req, byte(len(password)))
+	req = append(req, []byte(password)...)
+
+	if _, err := conn.Write(req); err != nil {
+		return fmt.Errorf("socks5 auth request failed: %w", err)
+	}
+
+	resp := make([]byte, 2)
+	if _, err := io.ReadFull(conn, resp); err != nil {
+		return fmt.Errorf("socks5 auth response failed: %w", err)
+	}
+	if resp[1] != 0x00 {
+		return fmt.Errorf("socks5 authentication rejected")
+	}
+
+	return nil
+}
+
+// socks5Connect sends the CONNECT request (RFC 1928 section 4) for
+// targetAddr and consumes the server's reply.
+func socks5Connect(conn net.Conn, targetAddr string, resolveLocally bool) error {
+	host, portStr, err := net.SplitHostPort(targetAddr)
+	if err != nil {
+		host, portStr = targetAddr, "443"
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return fmt.Errorf("socks5: invalid target port %q: %w", portStr, err)
+	}
+
+	req := []byte{0x05, 0x01, 0x00} // VER, CMD=CONNECT, RSV
+
+	if resolveLocally {
+		ip := net.ParseIP(host)
+		if ip == nil {
+			addrs, err := net.LookupIP(host)
+			if err != nil || len(addrs) == 0 {
+				return fmt.Errorf("socks5: failed to resolve %s: %w", host, err)
+			}
+			ip = addrs[0]
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			req = append(req, 0x01)
+			req = append(req, ip4...)
+		} else {
+			req = append(req, 0x04)
+			req = append(req, ip.To16()...)
+		}
+	} else {
+		if len(host) > 255 {
+			return fmt.Errorf("socks5: hostname %q too long for ATYP_DOMAINNAME", host)
+		}
+		req = append(req, 0x03, byte(len(host)))
+		req = append(req, []byte(host)...)
+	}
+
+	req = append(req, byte(port>>8), byte(port&0xff))
+
+	if _, err := conn.Write(req); err != nil {
+		return fmt.Errorf("socks5 connect request failed: %w", err)
+	}
+
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return fmt.Errorf("socks5 connect response failed: %w", err)
+	}
+	if header[0] != 0x05 {
+		return fmt.Errorf("socks5: unexpected version %d in reply", header[0])
+	}
+	if header[1] != 0x00 {
+		return fmt.Errorf("socks5 connect to %s failed: %s", targetAddr, socks5ReplyError(header[1]))
+	}
+
+	// Consume the bound address the server returns; its length depends on ATYP.
+	switch header[3] {
+	case 0x01: // IPv4 + port
+		if _, err := io.ReadFull(conn, make([]byte, net.IPv4len+2)); err != nil {
+			return fmt.Errorf("socks5 reply bound address read failed: %w", err)
+		}
+	case 0x03: // domain + port
+		lenBuf := make([]byte, 1)
+		if _, err := io.ReadFull(conn, lenBuf); err != nil {
+			return fmt.Errorf("socks5 reply bound address read failed: %w", err)
+		}
+		if _, err := io.ReadFull(conn, make([]byte, int(lenBuf[0])+2)); err != nil {
+			return fmt.Errorf("socks5 reply bound address read failed: %w", err)
+		}
+	case 0x04: // IPv6 + port
+		if _, err := io.ReadFull(conn, make([]byte, net.IPv6len+2)); err != nil {
+			return fmt.Errorf("socks5 reply bound address read failed: %w", err)
+		}
+	default:
+		return fmt.Errorf("socks5 reply: unknown address type %d", header[3])
+	}
+
+	return nil
+}
+
+func socks5ReplyError(code byte) string {
+	switch code {
+	case 0x01:
+		return "general SOCKS server failure"
+	case 0x02:
+		return "connection not allowed by ruleset"
+	case 0x03:
+		return "network unreachable"
+	case 0x04:
+		return "host unreachable"
+	case 0x05:
+		return "connection refused"
+	case 0x06:
+		return "TTL expired"
+	case 0x07:
+		return "command not supported"
+	case 0x08:
+		return "address type not supported"
+	default:
+		return fmt.Sprintf("unknown error code %d", code)
+	}
+}
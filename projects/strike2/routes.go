@@ -2,12 +2,16 @@ package strike2
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/Goga74/platform2/internal/transport"
 	"github.com/Goga74/platform2/projects/strike2/captcha"
 	"github.com/Goga74/platform2/projects/strike2/scraper"
+	"github.com/Goga74/platform2/projects/strike2/store"
 	"github.com/gin-gonic/gin"
 )
 
@@ -22,11 +26,22 @@ func (s *Strike2) RegisterRoutes(rg *gin.RouterGroup) {
 		v1.POST("/fetch", s.fetchHandler)
 		v1.POST("/batch", s.batchHandler)
 		v1.GET("/fingerprints", s.fingerprintsHandler)
+		v1.POST("/fingerprints/pin", s.pinFingerprintHandler)
+		v1.DELETE("/fingerprints/pin/:host", s.unpinFingerprintHandler)
+
+		v1.POST("/enqueue", s.enqueueHandler)
+		v1.GET("/jobs", s.listJobsHandler)
+		v1.GET("/jobs/:id", s.getJobHandler)
+		v1.DELETE("/jobs/:id", s.deleteJobHandler)
+		v1.DELETE("/hosts/:host", s.deleteHostHandler)
 
 		captchaGroup := v1.Group("/captcha")
 		{
 			captchaGroup.POST("/solve/amazon-waf", s.solveAmazonWAFHandler)
+			captchaGroup.POST("/solve/:type", s.solveCaptchaHandler)
 			captchaGroup.GET("/balance", s.getCaptchaBalanceHandler)
+			captchaGroup.GET("/pending", s.getPendingCaptchasHandler)
+			captchaGroup.POST("/:id/solution", s.submitCaptchaSolutionHandler)
 		}
 	}
 }
@@ -107,6 +122,107 @@ func (s *Strike2) batchHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
+// enqueueHandler queues a fetch request onto its target host's delivery
+// queue and returns immediately with a job ID to poll.
+func (s *Strike2) enqueueHandler(c *gin.Context) {
+	var req scraper.FetchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	if req.URL == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "url is required",
+		})
+		return
+	}
+
+	job, err := s.scraper.Enqueue(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"id":     job.ID,
+		"status": job.Status,
+	})
+}
+
+// getJobHandler returns the current state of a previously enqueued job.
+func (s *Strike2) getJobHandler(c *gin.Context) {
+	job, ok := s.scraper.GetJob(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "job not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// listJobsHandler returns persisted job history, optionally filtered by
+// since (RFC3339 timestamp, default the zero time) and status. Requires a
+// configured Store; returns 503 when Strike2 is running without one.
+func (s *Strike2) listJobsHandler(c *gin.Context) {
+	var since time.Time
+	if v := c.Query("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid since: expected RFC3339 timestamp",
+			})
+			return
+		}
+		since = t
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	jobs, err := s.scraper.ListJobs(ctx, since, c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "job history unavailable: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"jobs":  jobs,
+		"total": len(jobs),
+	})
+}
+
+// deleteJobHandler cancels a still-queued job by ID.
+func (s *Strike2) deleteJobHandler(c *gin.Context) {
+	if !s.scraper.CancelJob(c.Param("id")) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "job not found or already running",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"cancelled": true,
+	})
+}
+
+// deleteHostHandler purges every job still queued for the given host.
+func (s *Strike2) deleteHostHandler(c *gin.Context) {
+	removed := s.scraper.PurgeHost(c.Param("host"))
+
+	c.JSON(http.StatusOK, gin.H{
+		"removed": removed,
+	})
+}
+
 // fingerprintsHandler returns available fingerprints
 func (s *Strike2) fingerprintsHandler(c *gin.Context) {
 	fps := transport.GetFingerprints()
@@ -124,6 +240,50 @@ func (s *Strike2) fingerprintsHandler(c *gin.Context) {
 	})
 }
 
+// pinFingerprintHandler forces a host to always use a specific fingerprint,
+// overriding the configured FingerprintStrategy, for both the proxy and scraper.
+func (s *Strike2) pinFingerprintHandler(c *gin.Context) {
+	var body struct {
+		Host        string `json:"host"`
+		Fingerprint string `json:"fingerprint"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	if body.Host == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "host is required",
+		})
+		return
+	}
+
+	if err := s.PinFingerprint(body.Host, body.Fingerprint); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"host":        body.Host,
+		"fingerprint": body.Fingerprint,
+		"pinned":      true,
+	})
+}
+
+// unpinFingerprintHandler removes a fingerprint pin set via pinFingerprintHandler.
+func (s *Strike2) unpinFingerprintHandler(c *gin.Context) {
+	s.UnpinFingerprint(c.Param("host"))
+
+	c.JSON(http.StatusOK, gin.H{
+		"unpinned": true,
+	})
+}
+
 // solveAmazonWAFHandler handles Amazon WAF captcha solving requests
 func (s *Strike2) solveAmazonWAFHandler(c *gin.Context) {
 	if s.captchaSolver == nil {
@@ -155,7 +315,59 @@ func (s *Strike2) solveAmazonWAFHandler(c *gin.Context) {
 		return
 	}
 
-	result, err := s.captchaSolver.SolveAmazonWAF(req)
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Minute)
+	defer cancel()
+
+	result, err := captcha.SolveAmazonWAF(ctx, s.captchaSolver, req)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	s.persistCaptchaSolution(ctx, captcha.ChallengeAmazonWAF, req.URL, result.Token, result.SolvedIn, result.Cost)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":      true,
+		"token":        result.Token,
+		"solved_in_ms": result.SolvedIn.Milliseconds(),
+		"cost":         result.Cost,
+	})
+}
+
+// solveCaptchaHandler handles generic captcha solving requests for any
+// supported challenge type (hcaptcha, recaptcha_v2, recaptcha_v3,
+// turnstile, geetest, amazon_waf).
+func (s *Strike2) solveCaptchaHandler(c *gin.Context) {
+	if s.captchaSolver == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "captcha solver not configured - provide a STRIKE2_CAPTCHA_PROVIDER API key",
+		})
+		return
+	}
+
+	var challenge captcha.Challenge
+	if err := c.ShouldBindJSON(&challenge); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request body: " + err.Error(),
+		})
+		return
+	}
+	challenge.Type = captcha.ChallengeType(c.Param("type"))
+
+	if challenge.URL == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "url is required",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Minute)
+	defer cancel()
+
+	result, err := s.captchaSolver.Solve(ctx, challenge)
 	if err != nil {
 		c.JSON(http.StatusOK, gin.H{
 			"success": false,
@@ -164,6 +376,8 @@ func (s *Strike2) solveAmazonWAFHandler(c *gin.Context) {
 		return
 	}
 
+	s.persistCaptchaSolution(ctx, challenge.Type, challenge.URL, result.Token, result.SolvedIn, result.Cost)
+
 	c.JSON(http.StatusOK, gin.H{
 		"success":      true,
 		"token":        result.Token,
@@ -172,16 +386,95 @@ func (s *Strike2) solveAmazonWAFHandler(c *gin.Context) {
 	})
 }
 
-// getCaptchaBalanceHandler returns 2Captcha account balance
+// persistCaptchaSolution best-effort persists a solved challenge to the
+// configured Store. Failures are logged, not surfaced to the caller: the
+// solve itself already succeeded.
+func (s *Strike2) persistCaptchaSolution(ctx context.Context, challengeType captcha.ChallengeType, url, token string, solvedIn time.Duration, cost string) {
+	if s.store == nil {
+		return
+	}
+
+	rec := store.CaptchaSolutionRecord{
+		ID:            generateSolutionID(),
+		ChallengeType: string(challengeType),
+		URL:           url,
+		Token:         token,
+		Provider:      s.captchaProvider,
+		Cost:          cost,
+		SolvedInMs:    solvedIn.Milliseconds(),
+		CreatedAt:     time.Now(),
+	}
+
+	if err := s.store.SaveCaptchaSolution(ctx, rec); err != nil {
+		log.Printf("[Strike2] failed to persist captcha solution: %v", err)
+	}
+}
+
+func generateSolutionID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic("crypto/rand failed: " + err.Error())
+	}
+	return "sol_" + hex.EncodeToString(b)
+}
+
+// getPendingCaptchasHandler lists challenges parked for a human operator
+// when the manual captcha provider is configured.
+func (s *Strike2) getPendingCaptchasHandler(c *gin.Context) {
+	if s.manualSolver == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "manual captcha provider not configured (STRIKE2_CAPTCHA_PROVIDER=manual)",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pending": s.manualSolver.Pending(),
+	})
+}
+
+// submitCaptchaSolutionHandler delivers an operator-provided token for a
+// pending manual challenge.
+func (s *Strike2) submitCaptchaSolutionHandler(c *gin.Context) {
+	if s.manualSolver == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "manual captcha provider not configured (STRIKE2_CAPTCHA_PROVIDER=manual)",
+		})
+		return
+	}
+
+	var body struct {
+		Token string `json:"token"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	if !s.manualSolver.Submit(c.Param("id"), body.Token) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "pending challenge not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"submitted": true,
+	})
+}
+
+// getCaptchaBalanceHandler returns the active captcha provider's account balance
 func (s *Strike2) getCaptchaBalanceHandler(c *gin.Context) {
 	if s.captchaSolver == nil {
 		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"error": "captcha solver not configured - provide CAPTCHA_API_KEY",
+			"error": "captcha solver not configured - provide a STRIKE2_CAPTCHA_PROVIDER API key",
 		})
 		return
 	}
 
-	balance, err := s.captchaSolver.GetBalance()
+	balance, err := s.captchaSolver.Balance()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "failed to get balance: " + err.Error(),
@@ -0,0 +1,604 @@
+package scraper
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	mathrand "math/rand"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Goga74/platform2/internal/metrics"
+	"github.com/Goga74/platform2/projects/strike2/store"
+)
+
+// JobStatus is the lifecycle state of an enqueued fetch job.
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+	JobCancelled JobStatus = "cancelled"
+)
+
+const (
+	defaultMaxAttempts  = 5
+	defaultBackoffBase  = 1 * time.Second
+	defaultBackoffCap   = 5 * time.Minute
+	defaultBadHostTrips = 3
+	defaultHostCoolOff  = 2 * time.Minute
+)
+
+// AttemptRecord captures the outcome of a single delivery attempt for a job.
+type AttemptRecord struct {
+	Attempt    int    `json:"attempt"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// Job tracks a FetchRequest as it moves through its target host's delivery queue.
+type Job struct {
+	ID        string          `json:"id"`
+	Host      string          `json:"host"`
+	Request   FetchRequest    `json:"request"`
+	Status    JobStatus       `json:"status"`
+	Attempts  []AttemptRecord `json:"attempts,omitempty"`
+	Response  *FetchResponse  `json:"response,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+
+	mu   sync.Mutex
+	done chan struct{}
+}
+
+func newJob(host string, req FetchRequest) *Job {
+	now := time.Now()
+	return &Job{
+		ID:        generateJobID(),
+		Host:      host,
+		Request:   req,
+		Status:    JobQueued,
+		CreatedAt: now,
+		UpdatedAt: now,
+		done:      make(chan struct{}),
+	}
+}
+
+func (j *Job) snapshot() *Job {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	cp := *j
+	cp.Attempts = append([]AttemptRecord(nil), j.Attempts...)
+	cp.mu = sync.Mutex{}
+	cp.done = nil
+	return &cp
+}
+
+func (j *Job) isTerminal() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.Status == JobSucceeded || j.Status == JobFailed || j.Status == JobCancelled
+}
+
+func (j *Job) setRunning() {
+	j.mu.Lock()
+	j.Status = JobRunning
+	j.UpdatedAt = time.Now()
+	j.mu.Unlock()
+}
+
+func (j *Job) recordAttempt(rec AttemptRecord) {
+	j.mu.Lock()
+	j.Attempts = append(j.Attempts, rec)
+	j.UpdatedAt = time.Now()
+	j.mu.Unlock()
+}
+
+func (j *Job) finish(status JobStatus, resp *FetchResponse) {
+	j.mu.Lock()
+	if j.Status == JobSucceeded || j.Status == JobFailed || j.Status == JobCancelled {
+		j.mu.Unlock()
+		return
+	}
+	j.Status = status
+	j.Response = resp
+	j.UpdatedAt = time.Now()
+	done := j.done
+	j.mu.Unlock()
+	close(done)
+}
+
+func (j *Job) cancel() bool {
+	j.mu.Lock()
+	if j.Status != JobQueued {
+		j.mu.Unlock()
+		return false
+	}
+	j.Status = JobCancelled
+	j.UpdatedAt = time.Now()
+	done := j.done
+	j.mu.Unlock()
+	close(done)
+	return true
+}
+
+func (j *Job) wait(done <-chan struct{}) *FetchResponse {
+	select {
+	case <-j.done:
+	case <-done:
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.Response != nil {
+		return j.Response
+	}
+	return &FetchResponse{URL: j.Request.URL, Error: "cancelled before completion"}
+}
+
+// hostQueue is a persistent FIFO of jobs targeting a single host, drained by
+// one long-lived delivery worker goroutine.
+type hostQueue struct {
+	host  string
+	mu    sync.Mutex
+	cond  *sync.Cond
+	items []*Job
+}
+
+func newHostQueue(host string) *hostQueue {
+	q := &hostQueue{host: host}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// reportDepth updates strike2_host_queue_depth for this host. Callers must
+// hold q.mu.
+func (q *hostQueue) reportDepth() {
+	metrics.HostQueueDepth.Set(float64(len(q.items)), q.host)
+}
+
+func (q *hostQueue) push(job *Job) {
+	q.mu.Lock()
+	q.items = append(q.items, job)
+	q.reportDepth()
+	q.mu.Unlock()
+	q.cond.Signal()
+}
+
+// pop blocks until a job is available.
+func (q *hostQueue) pop() *Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.items) == 0 {
+		q.cond.Wait()
+	}
+	job := q.items[0]
+	q.items = q.items[1:]
+	q.reportDepth()
+	return job
+}
+
+// removeQueued deletes a not-yet-started job by ID. Returns true if found.
+func (q *hostQueue) removeQueued(id string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, j := range q.items {
+		if j.ID == id {
+			q.items = append(q.items[:i], q.items[i+1:]...)
+			q.reportDepth()
+			return true
+		}
+	}
+	return false
+}
+
+// drain removes and returns every job still queued (used to purge a host).
+func (q *hostQueue) drain() []*Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	items := q.items
+	q.items = nil
+	q.reportDepth()
+	return items
+}
+
+// hostState tracks consecutive failures so a host can be fast-failed during
+// a cool-off window instead of piling up retries.
+type hostState struct {
+	mu         sync.Mutex
+	failStreak int
+	badUntil   time.Time
+}
+
+func (h *hostState) coolOffUntil() (time.Time, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.badUntil.IsZero() || time.Now().After(h.badUntil) {
+		return time.Time{}, false
+	}
+	return h.badUntil, true
+}
+
+func (h *hostState) recordSuccess() {
+	h.mu.Lock()
+	h.failStreak = 0
+	h.badUntil = time.Time{}
+	h.mu.Unlock()
+}
+
+func (h *hostState) recordFailure(trips int, coolOff time.Duration) {
+	h.mu.Lock()
+	h.failStreak++
+	if h.failStreak >= trips {
+		h.badUntil = time.Now().Add(coolOff)
+	}
+	h.mu.Unlock()
+}
+
+// getOrCreateHostQueue returns the persistent queue for host, starting its
+// delivery worker the first time the host is seen.
+func (s *ScraperService) getOrCreateHostQueue(host string) *hostQueue {
+	s.hostQueuesMu.Lock()
+	q, ok := s.hostQueues[host]
+	if !ok {
+		q = newHostQueue(host)
+		s.hostQueues[host] = q
+		go s.runHostWorker(host, q)
+	}
+	s.hostQueuesMu.Unlock()
+	return q
+}
+
+func (s *ScraperService) getHostState(host string) *hostState {
+	s.hostStatesMu.Lock()
+	defer s.hostStatesMu.Unlock()
+	st, ok := s.hostStates[host]
+	if !ok {
+		st = &hostState{}
+		s.hostStates[host] = st
+	}
+	return st
+}
+
+// runHostWorker drains q forever, one job at a time, applying retry/backoff
+// and the host cool-off policy between attempts.
+func (s *ScraperService) runHostWorker(host string, q *hostQueue) {
+	state := s.getHostState(host)
+
+	for {
+		job := q.pop()
+		if job.isTerminal() {
+			continue
+		}
+
+		if until, bad := state.coolOffUntil(); bad {
+			s.finishJob(job, JobFailed, &FetchResponse{
+				URL:   job.Request.URL,
+				Error: fmt.Sprintf("host %s is in cool-off until %s", host, until.Format(time.RFC3339)),
+			})
+			continue
+		}
+
+		rs := s.retrySettingsFor(job.Request)
+
+		job.setRunning()
+		attempt := len(job.Attempts) + 1
+
+		var attemptCtx context.Context = context.Background()
+		cancelAttempt := func() {}
+		if pol := job.Request.RetryPolicy; pol != nil && pol.PerAttemptTimeout > 0 {
+			attemptCtx, cancelAttempt = context.WithTimeout(attemptCtx, pol.PerAttemptTimeout)
+		}
+
+		attemptStart := time.Now()
+		resp, err := s.doFetch(attemptCtx, job.Request)
+		duration := time.Since(attemptStart)
+		cancelAttempt()
+
+		rec := AttemptRecord{Attempt: attempt, DurationMs: duration.Milliseconds()}
+		if resp != nil {
+			rec.StatusCode = resp.StatusCode
+		}
+		if err != nil {
+			rec.Error = err.Error()
+		}
+		job.recordAttempt(rec)
+
+		if err == nil && !rs.isRetryableStatus(resp.StatusCode) {
+			state.recordSuccess()
+			s.finishJob(job, JobSucceeded, withAttemptLog(resp, job))
+			continue
+		}
+
+		transient := err != nil && rs.isRetryableErr(err)
+		if resp != nil && rs.isRetryableStatus(resp.StatusCode) {
+			transient = true
+		}
+
+		if !transient || attempt >= rs.maxAttempts {
+			state.recordFailure(s.badHostTrips, s.hostCoolOff)
+			if resp == nil {
+				resp = &FetchResponse{URL: job.Request.URL}
+			}
+			if resp.Error == "" && err != nil {
+				resp.Error = err.Error()
+			}
+			s.finishJob(job, JobFailed, withAttemptLog(resp, job))
+			continue
+		}
+
+		backoff := backoffWithJitter(attempt, rs.backoffBase, rs.backoffCap)
+		time.AfterFunc(backoff, func() {
+			q.push(job)
+		})
+	}
+}
+
+// withAttemptLog stamps resp with job's full attempt history before it's
+// returned to the caller.
+func withAttemptLog(resp *FetchResponse, job *Job) *FetchResponse {
+	job.mu.Lock()
+	resp.Attempts = len(job.Attempts)
+	resp.AttemptLog = append([]AttemptRecord(nil), job.Attempts...)
+	job.mu.Unlock()
+	return resp
+}
+
+// retrySettings is the effective retry/backoff configuration for a single
+// job, after applying any per-request RetryPolicy override on top of the
+// service-wide defaults.
+type retrySettings struct {
+	maxAttempts   int
+	backoffBase   time.Duration
+	backoffCap    time.Duration
+	retryOn       map[int]bool
+	retryOnNetErr bool
+}
+
+// retrySettingsFor resolves req's effective retry settings, falling back to
+// the service defaults for anything its RetryPolicy leaves unset.
+func (s *ScraperService) retrySettingsFor(req FetchRequest) retrySettings {
+	rs := retrySettings{
+		maxAttempts:   s.maxAttempts,
+		backoffBase:   s.backoffBase,
+		backoffCap:    s.backoffCap,
+		retryOnNetErr: true,
+	}
+
+	pol := req.RetryPolicy
+	if pol == nil {
+		return rs
+	}
+
+	if pol.MaxAttempts > 0 {
+		rs.maxAttempts = pol.MaxAttempts
+	}
+	if pol.Backoff > 0 {
+		rs.backoffBase = pol.Backoff
+	}
+	if len(pol.RetryOn) > 0 {
+		rs.retryOn = make(map[int]bool, len(pol.RetryOn))
+		for _, code := range pol.RetryOn {
+			rs.retryOn[code] = true
+		}
+	}
+	if pol.RetryOnNetErr != nil {
+		rs.retryOnNetErr = *pol.RetryOnNetErr
+	}
+	return rs
+}
+
+// isRetryableStatus reports whether status should trigger a retry, using the
+// RetryPolicy's explicit RetryOn list if one was given, or the default
+// "retry on 5xx" behavior otherwise.
+func (rs retrySettings) isRetryableStatus(status int) bool {
+	if rs.retryOn != nil {
+		return rs.retryOn[status]
+	}
+	return isTransientStatus(status)
+}
+
+// isRetryableErr reports whether err should trigger a retry.
+func (rs retrySettings) isRetryableErr(err error) bool {
+	if !rs.retryOnNetErr {
+		return false
+	}
+	return isTransientErr(err)
+}
+
+// finishJob marks job terminal and, if a Store is configured, best-effort
+// persists its final snapshot. Persistence failures are logged, not fatal:
+// the job's in-memory/queue-visible result is unaffected either way.
+func (s *ScraperService) finishJob(job *Job, status JobStatus, resp *FetchResponse) {
+	job.finish(status, resp)
+	s.persistJob(job)
+}
+
+func (s *ScraperService) persistJob(job *Job) {
+	if s.store == nil {
+		return
+	}
+
+	snap := job.snapshot()
+
+	reqJSON, err := json.Marshal(snap.Request)
+	if err != nil {
+		log.Printf("[Scraper] failed to encode job %s for persistence: %v", snap.ID, err)
+		return
+	}
+
+	var attemptsJSON []byte
+	if len(snap.Attempts) > 0 {
+		attemptsJSON, err = json.Marshal(snap.Attempts)
+		if err != nil {
+			log.Printf("[Scraper] failed to encode attempts for job %s: %v", snap.ID, err)
+			return
+		}
+	}
+
+	var respJSON []byte
+	if snap.Response != nil {
+		respJSON, err = json.Marshal(snap.Response)
+		if err != nil {
+			log.Printf("[Scraper] failed to encode response for job %s: %v", snap.ID, err)
+			return
+		}
+	}
+
+	rec := store.JobRecord{
+		ID:        snap.ID,
+		Host:      snap.Host,
+		Request:   reqJSON,
+		Status:    string(snap.Status),
+		Attempts:  attemptsJSON,
+		Response:  respJSON,
+		CreatedAt: snap.CreatedAt,
+		UpdatedAt: snap.UpdatedAt,
+	}
+
+	if err := s.store.SaveJob(context.Background(), rec); err != nil {
+		log.Printf("[Scraper] failed to persist job %s: %v", snap.ID, err)
+	}
+}
+
+// backoffFor returns base * 2^(attempt-1), capped at max.
+func backoffFor(attempt int, base, max time.Duration) time.Duration {
+	d := base * time.Duration(math.Pow(2, float64(attempt-1)))
+	if d > max {
+		return max
+	}
+	return d
+}
+
+// backoffWithJitter returns backoffFor(attempt, base, max) with up to ±20%
+// jitter applied, to avoid every job targeting a host retrying in lockstep.
+func backoffWithJitter(attempt int, base, max time.Duration) time.Duration {
+	d := backoffFor(attempt, base, max)
+	jitter := (mathrand.Float64()*0.4 - 0.2) * float64(d)
+	return d + time.Duration(jitter)
+}
+
+func isTransientStatus(status int) bool {
+	return status >= 500 && status < 600
+}
+
+func isTransientErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	msg := err.Error()
+	for _, marker := range []string{
+		"connection reset",
+		"connection refused",
+		"EOF",
+		"handshake failure",
+		"TLS handshake failed",
+		"context deadline exceeded",
+		"broken pipe",
+	} {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// Enqueue queues req onto its target host's delivery queue and returns
+// immediately with a Job the caller can poll.
+func (s *ScraperService) Enqueue(req FetchRequest) (*Job, error) {
+	host := hostOf(req.URL)
+	if host == "" {
+		return nil, fmt.Errorf("could not determine host for url %q", req.URL)
+	}
+
+	job := newJob(host, req)
+
+	s.jobsMu.Lock()
+	s.jobs[job.ID] = job
+	s.jobsMu.Unlock()
+
+	s.getOrCreateHostQueue(host).push(job)
+	s.persistJob(job)
+	return job, nil
+}
+
+// GetJob returns a point-in-time snapshot of a job by ID.
+func (s *ScraperService) GetJob(id string) (*Job, bool) {
+	s.jobsMu.RLock()
+	job, ok := s.jobs[id]
+	s.jobsMu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	return job.snapshot(), true
+}
+
+// CancelJob removes a still-queued job by ID. Returns false if the job is
+// unknown or has already started running.
+func (s *ScraperService) CancelJob(id string) bool {
+	s.jobsMu.RLock()
+	job, ok := s.jobs[id]
+	s.jobsMu.RUnlock()
+	if !ok {
+		return false
+	}
+
+	if !job.cancel() {
+		return false
+	}
+
+	s.hostQueuesMu.Lock()
+	q, ok := s.hostQueues[job.Host]
+	s.hostQueuesMu.Unlock()
+	if ok {
+		q.removeQueued(id)
+	}
+
+	return true
+}
+
+// PurgeHost drops every job still queued for host and returns how many were removed.
+func (s *ScraperService) PurgeHost(host string) int {
+	s.hostQueuesMu.Lock()
+	q, ok := s.hostQueues[host]
+	s.hostQueuesMu.Unlock()
+	if !ok {
+		return 0
+	}
+
+	removed := q.drain()
+	for _, job := range removed {
+		job.cancel()
+	}
+	return len(removed)
+}
+
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+func generateJobID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic("crypto/rand failed: " + err.Error())
+	}
+	return "job_" + hex.EncodeToString(b)
+}
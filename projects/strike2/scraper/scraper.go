@@ -3,14 +3,21 @@ package scraper
 import (
 	"compress/gzip"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/Goga74/platform2/internal/metrics"
 	"github.com/Goga74/platform2/internal/transport"
+	"github.com/Goga74/platform2/projects/strike2/logging"
+	"github.com/Goga74/platform2/projects/strike2/store"
 )
 
 // FetchRequest represents a single fetch request
@@ -20,6 +27,33 @@ type FetchRequest struct {
 	Headers     map[string]string `json:"headers,omitempty"`
 	Method      string            `json:"method,omitempty"`
 	Body        string            `json:"body,omitempty"`
+
+	// RetryPolicy overrides the scraper's default retry/backoff behavior for
+	// this request only. A nil RetryPolicy uses the service-wide defaults.
+	RetryPolicy *RetryPolicy `json:"retry_policy,omitempty"`
+}
+
+// RetryPolicy controls how a single FetchRequest is retried across its
+// host's delivery queue (see delivery.go's runHostWorker). Any zero field
+// falls back to the service-wide default for that setting.
+type RetryPolicy struct {
+	MaxAttempts int           `json:"max_attempts,omitempty"`
+	Backoff     time.Duration `json:"backoff,omitempty"`
+
+	// RetryOn lists the status codes that should be retried. A nil slice
+	// falls back to the default "retry on 5xx" behavior.
+	RetryOn []int `json:"retry_on,omitempty"`
+
+	// RetryOnNetErr controls whether transient network errors (timeouts,
+	// connection resets, ...) are retried. Defaults to true when nil.
+	RetryOnNetErr *bool `json:"retry_on_net_err,omitempty"`
+
+	// PerAttemptTimeout bounds a single attempt; zero means no per-attempt timeout.
+	PerAttemptTimeout time.Duration `json:"per_attempt_timeout,omitempty"`
+
+	// TotalTimeout bounds every attempt combined; zero defers to the
+	// caller's context deadline, if any.
+	TotalTimeout time.Duration `json:"total_timeout,omitempty"`
 }
 
 // FetchResponse represents the result of a fetch operation
@@ -30,6 +64,16 @@ type FetchResponse struct {
 	Body       string            `json:"body"`
 	Error      string            `json:"error,omitempty"`
 	Duration   int64             `json:"duration_ms"`
+
+	// Fingerprint is the name of the TLS fingerprint actually used for this
+	// fetch, whether it came from an explicit FetchRequest.Fingerprint or
+	// from the service's configured FingerprintStrategy.
+	Fingerprint string `json:"fingerprint,omitempty"`
+
+	// Attempts is the number of attempts made before this response was returned.
+	Attempts int `json:"attempts,omitempty"`
+	// AttemptLog records every attempt's outcome, in order.
+	AttemptLog []AttemptRecord `json:"attempt_log,omitempty"`
 }
 
 // BatchRequest represents a batch of fetch requests
@@ -45,41 +89,102 @@ type BatchResponse struct {
 	Failed  int             `json:"failed"`
 }
 
-// ScraperService manages concurrent fetch operations
+// ScraperService manages concurrent fetch operations. Requests are delivered
+// through a persistent per-host queue (see delivery.go) so a slow or failing
+// host can't starve the rest, and workerPool bounds how many fetches run
+// concurrently across all hosts at once.
 type ScraperService struct {
 	workerPool  chan struct{}
 	clientCache map[string]*transport.UTLSClient
 	cacheMu     sync.RWMutex
+
+	maxAttempts  int
+	backoffBase  time.Duration
+	backoffCap   time.Duration
+	badHostTrips int
+	hostCoolOff  time.Duration
+
+	hostQueues   map[string]*hostQueue
+	hostQueuesMu sync.Mutex
+
+	hostStates   map[string]*hostState
+	hostStatesMu sync.Mutex
+
+	jobs   map[string]*Job
+	jobsMu sync.RWMutex
+
+	store    store.Store
+	cacheTTL time.Duration
+
+	fpSelector    *transport.FingerprintSelector
+	upstreamProxy string
 }
 
-// NewScraperService creates a new scraper with specified worker pool size
-func NewScraperService(poolSize int) *ScraperService {
+// NewScraperService creates a new scraper with the specified worker pool
+// size. st persists jobs and caches responses; cacheTTL of zero disables the
+// response cache entirely (jobs are still persisted if st is non-nil).
+// fpSelector picks the fingerprint for requests that don't set one
+// explicitly; a nil fpSelector falls back to transport.GetRandomFingerprint
+// (which returns the first of transport.GetFingerprints), matching the
+// service's pre-FingerprintStrategy behavior.
+// upstreamProxy, if set, routes every fetch through that proxy via CONNECT
+// (see transport.Config.UpstreamProxy); empty means fetches dial targets
+// directly.
+func NewScraperService(poolSize int, st store.Store, cacheTTL time.Duration, fpSelector *transport.FingerprintSelector, upstreamProxy string) *ScraperService {
 	return &ScraperService{
 		workerPool:  make(chan struct{}, poolSize),
 		clientCache: make(map[string]*transport.UTLSClient),
+
+		maxAttempts:  defaultMaxAttempts,
+		backoffBase:  defaultBackoffBase,
+		backoffCap:   defaultBackoffCap,
+		badHostTrips: defaultBadHostTrips,
+		hostCoolOff:  defaultHostCoolOff,
+
+		hostQueues: make(map[string]*hostQueue),
+		hostStates: make(map[string]*hostState),
+		jobs:       make(map[string]*Job),
+
+		store:    st,
+		cacheTTL: cacheTTL,
+
+		fpSelector:    fpSelector,
+		upstreamProxy: upstreamProxy,
+	}
+}
+
+// ListJobs returns persisted jobs created at or after since, optionally
+// filtered by status. It reads from the configured Store (Postgres or
+// in-memory) rather than the live jobs map, so it also returns jobs that
+// have since been garbage collected from memory.
+func (s *ScraperService) ListJobs(ctx context.Context, since time.Time, status string) ([]store.JobRecord, error) {
+	if s.store == nil {
+		return nil, fmt.Errorf("no job store configured")
 	}
+	return s.store.ListJobs(ctx, since, status)
 }
 
-// getClient returns cached or creates new uTLS client for fingerprint
-func (s *ScraperService) getClient(fingerprintName string) (*transport.UTLSClient, error) {
+// getClient returns a cached client for fp, or creates and caches a new one.
+func (s *ScraperService) getClient(fp transport.Fingerprint) (*transport.UTLSClient, error) {
 	s.cacheMu.RLock()
-	if client, ok := s.clientCache[fingerprintName]; ok {
+	if client, ok := s.clientCache[fp.Name]; ok {
 		s.cacheMu.RUnlock()
 		return client, nil
 	}
 	s.cacheMu.RUnlock()
 
-	fp := s.findFingerprint(fingerprintName)
-
-	client, err := transport.NewUTLSClient(fp)
+	client, err := transport.NewUTLSClient(fp, transport.Config{UpstreamProxy: s.upstreamProxy})
 	if err != nil {
 		return nil, err
 	}
 
 	s.cacheMu.Lock()
-	s.clientCache[fingerprintName] = client
+	s.clientCache[fp.Name] = client
+	size := len(s.clientCache)
 	s.cacheMu.Unlock()
 
+	metrics.ClientCacheSize.Set(float64(size))
+
 	return client, nil
 }
 
@@ -99,34 +204,115 @@ func (s *ScraperService) findFingerprint(name string) transport.Fingerprint {
 	return transport.GetRandomFingerprint()
 }
 
-// FetchURL performs a single URL fetch with fingerprint spoofing
+// selectFingerprint resolves the Fingerprint to use for req: an explicit
+// req.Fingerprint always wins (matched by name, falling back to
+// transport.GetRandomFingerprint if unrecognized), otherwise the configured FingerprintSelector picks one
+// based on the service's FingerprintStrategy, keyed by req's target host.
+func (s *ScraperService) selectFingerprint(req FetchRequest) transport.Fingerprint {
+	if req.Fingerprint != "" {
+		return s.findFingerprint(req.Fingerprint)
+	}
+	if s.fpSelector != nil {
+		return s.fpSelector.Select(hostOf(req.URL))
+	}
+	return transport.GetRandomFingerprint()
+}
+
+// FetchURL performs a single URL fetch with fingerprint spoofing. The
+// request is delivered through its host's persistent queue (see
+// delivery.go) so it shares retry/backoff and cool-off handling with
+// everything else targeting that host; FetchURL simply waits for the
+// result or for ctx to be cancelled.
 func (s *ScraperService) FetchURL(ctx context.Context, req FetchRequest) FetchResponse {
+	traceID := logging.TraceID(ctx)
+	if traceID == "" {
+		traceID = logging.NewTraceID()
+	}
 	start := time.Now()
+	logging.Default.Info("scraper.fetch.start", logging.Fields{"trace_id": traceID, "url": req.URL})
 
-	select {
-	case s.workerPool <- struct{}{}:
-		defer func() { <-s.workerPool }()
-	case <-ctx.Done():
-		return FetchResponse{
-			URL:   req.URL,
-			Error: "context cancelled while waiting for worker",
-		}
+	if req.RetryPolicy != nil && req.RetryPolicy.TotalTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, req.RetryPolicy.TotalTimeout)
+		defer cancel()
 	}
 
-	client, err := s.getClient(req.Fingerprint)
+	job, err := s.Enqueue(req)
 	if err != nil {
-		return FetchResponse{
-			URL:      req.URL,
-			Error:    fmt.Sprintf("failed to create client: %v", err),
-			Duration: time.Since(start).Milliseconds(),
-		}
+		logging.Default.Error("scraper.fetch.enqueue_failed", logging.Fields{"trace_id": traceID, "url": req.URL, "error": err.Error()})
+		return FetchResponse{URL: req.URL, Error: err.Error()}
+	}
+
+	resp := job.wait(ctx.Done())
+	if ctx.Err() != nil {
+		s.CancelJob(job.ID)
+	}
+
+	host := hostOf(req.URL)
+	metrics.FetchDurationSeconds.Observe(time.Since(start).Seconds(), host)
+	metrics.RequestsTotal.Inc(resp.Fingerprint, strconv.Itoa(resp.StatusCode), host)
+
+	if resp.Error != "" {
+		logging.Default.Error("scraper.fetch.done", logging.Fields{"trace_id": traceID, "url": req.URL, "error": resp.Error})
+	} else {
+		logging.Default.Info("scraper.fetch.done", logging.Fields{"trace_id": traceID, "url": req.URL, "status_code": resp.StatusCode})
 	}
 
+	return *resp
+}
+
+// doFetch performs one network attempt for req, outside of any retry
+// policy. It is called by each host's delivery worker. ctx bounds the
+// attempt itself (see RetryPolicy.PerAttemptTimeout).
+func (s *ScraperService) doFetch(ctx context.Context, req FetchRequest) (*FetchResponse, error) {
+	start := time.Now()
+
 	method := req.Method
 	if method == "" {
 		method = "GET"
 	}
 
+	fp := s.selectFingerprint(req)
+
+	cacheKey := store.CacheKey{
+		Method:      method,
+		URL:         req.URL,
+		Fingerprint: fp.Name,
+		BodyHash:    bodyHash(req.Body),
+	}
+
+	if s.store != nil && s.cacheTTL > 0 {
+		if cached, err := s.store.GetCachedResponse(ctx, cacheKey, s.cacheTTL); err != nil {
+			log.Printf("[Scraper] response cache lookup failed for %s: %v", req.URL, err)
+		} else if cached != nil {
+			return &FetchResponse{
+				URL:         req.URL,
+				StatusCode:  cached.StatusCode,
+				Headers:     cached.Headers,
+				Body:        cached.Body,
+				Duration:    time.Since(start).Milliseconds(),
+				Fingerprint: fp.Name,
+			}, nil
+		}
+	}
+
+	s.workerPool <- struct{}{}
+	metrics.WorkerPoolInflight.Inc()
+	defer func() {
+		<-s.workerPool
+		metrics.WorkerPoolInflight.Dec()
+	}()
+
+	client, err := s.getClient(fp)
+	if err != nil {
+		return &FetchResponse{
+			URL:         req.URL,
+			Error:       fmt.Sprintf("failed to create client: %v", err),
+			Duration:    time.Since(start).Milliseconds(),
+			Fingerprint: fp.Name,
+		}, err
+	}
+
 	var body io.Reader
 	if req.Body != "" {
 		body = strings.NewReader(req.Body)
@@ -134,11 +320,12 @@ func (s *ScraperService) FetchURL(ctx context.Context, req FetchRequest) FetchRe
 
 	httpReq, err := http.NewRequestWithContext(ctx, method, req.URL, body)
 	if err != nil {
-		return FetchResponse{
-			URL:      req.URL,
-			Error:    fmt.Sprintf("failed to create request: %v", err),
-			Duration: time.Since(start).Milliseconds(),
-		}
+		return &FetchResponse{
+			URL:         req.URL,
+			Error:       fmt.Sprintf("failed to create request: %v", err),
+			Duration:    time.Since(start).Milliseconds(),
+			Fingerprint: fp.Name,
+		}, err
 	}
 
 	for key, value := range req.Headers {
@@ -147,11 +334,12 @@ func (s *ScraperService) FetchURL(ctx context.Context, req FetchRequest) FetchRe
 
 	resp, err := client.Do(httpReq)
 	if err != nil {
-		return FetchResponse{
-			URL:      req.URL,
-			Error:    fmt.Sprintf("request failed: %v", err),
-			Duration: time.Since(start).Milliseconds(),
-		}
+		return &FetchResponse{
+			URL:         req.URL,
+			Error:       fmt.Sprintf("request failed: %v", err),
+			Duration:    time.Since(start).Milliseconds(),
+			Fingerprint: fp.Name,
+		}, err
 	}
 	defer resp.Body.Close()
 
@@ -166,12 +354,13 @@ func (s *ScraperService) FetchURL(ctx context.Context, req FetchRequest) FetchRe
 
 	bodyBytes, err := io.ReadAll(io.LimitReader(reader, 10*1024*1024)) // 10MB limit
 	if err != nil {
-		return FetchResponse{
-			URL:        req.URL,
-			StatusCode: resp.StatusCode,
-			Error:      fmt.Sprintf("failed to read body: %v", err),
-			Duration:   time.Since(start).Milliseconds(),
-		}
+		return &FetchResponse{
+			URL:         req.URL,
+			StatusCode:  resp.StatusCode,
+			Error:       fmt.Sprintf("failed to read body: %v", err),
+			Duration:    time.Since(start).Milliseconds(),
+			Fingerprint: fp.Name,
+		}, err
 	}
 
 	headers := make(map[string]string)
@@ -179,17 +368,39 @@ func (s *ScraperService) FetchURL(ctx context.Context, req FetchRequest) FetchRe
 		headers[key] = resp.Header.Get(key)
 	}
 
-	return FetchResponse{
-		URL:        req.URL,
-		StatusCode: resp.StatusCode,
-		Headers:    headers,
-		Body:       string(bodyBytes),
-		Duration:   time.Since(start).Milliseconds(),
+	if s.store != nil && s.cacheTTL > 0 {
+		if err := s.store.PutCachedResponse(ctx, cacheKey, store.CachedResponse{
+			StatusCode: resp.StatusCode,
+			Headers:    headers,
+			Body:       string(bodyBytes),
+			FetchedAt:  time.Now(),
+		}); err != nil {
+			log.Printf("[Scraper] failed to cache response for %s: %v", req.URL, err)
+		}
 	}
+
+	return &FetchResponse{
+		URL:         req.URL,
+		StatusCode:  resp.StatusCode,
+		Headers:     headers,
+		Body:        string(bodyBytes),
+		Duration:    time.Since(start).Milliseconds(),
+		Fingerprint: fp.Name,
+	}, nil
 }
 
-// FetchBatch performs concurrent fetch of multiple URLs
+// bodyHash returns a hex-encoded SHA-256 digest of body, used as part of the
+// response cache key so requests with different payloads don't collide.
+func bodyHash(body string) string {
+	sum := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(sum[:])
+}
+
+// FetchBatch performs concurrent fetch of multiple URLs, all sharing a single
+// trace ID so the batch's per-item log lines can be correlated together.
 func (s *ScraperService) FetchBatch(ctx context.Context, batch BatchRequest) BatchResponse {
+	ctx = logging.WithTraceID(ctx, logging.NewTraceID())
+
 	results := make([]FetchResponse, len(batch.Requests))
 	var wg sync.WaitGroup
 
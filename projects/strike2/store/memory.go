@@ -0,0 +1,73 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore is an in-process Store used when STRIKE2_DATABASE_URL is not
+// configured. Nothing is persisted across restarts.
+type MemoryStore struct {
+	mu        sync.RWMutex
+	responses map[CacheKey]CachedResponse
+	jobs      map[string]JobRecord
+	captchas  []CaptchaSolutionRecord
+}
+
+// NewMemoryStore creates an empty in-memory Store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		responses: make(map[CacheKey]CachedResponse),
+		jobs:      make(map[string]JobRecord),
+	}
+}
+
+func (s *MemoryStore) GetCachedResponse(ctx context.Context, key CacheKey, ttl time.Duration) (*CachedResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	resp, ok := s.responses[key]
+	if !ok || time.Since(resp.FetchedAt) > ttl {
+		return nil, nil
+	}
+	return &resp, nil
+}
+
+func (s *MemoryStore) PutCachedResponse(ctx context.Context, key CacheKey, resp CachedResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responses[key] = resp
+	return nil
+}
+
+func (s *MemoryStore) SaveJob(ctx context.Context, job JobRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.ID] = job
+	return nil
+}
+
+func (s *MemoryStore) ListJobs(ctx context.Context, since time.Time, status string) ([]JobRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var jobs []JobRecord
+	for _, j := range s.jobs {
+		if j.CreatedAt.Before(since) {
+			continue
+		}
+		if status != "" && j.Status != status {
+			continue
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, nil
+}
+
+func (s *MemoryStore) SaveCaptchaSolution(ctx context.Context, rec CaptchaSolutionRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.captchas = append(s.captchas, rec)
+	return nil
+}
@@ -0,0 +1,6 @@
+package store
+
+import "embed"
+
+//go:embed migrations/*.sql
+var migrationFS embed.FS
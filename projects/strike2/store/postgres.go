@@ -0,0 +1,129 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Goga74/platform2/internal/common/database"
+)
+
+// PostgresStore persists Strike2 jobs, responses, and captcha solutions to
+// Postgres under the strike2 schema.
+type PostgresStore struct {
+	db *database.DB
+}
+
+// NewPostgresStore applies the embedded strike2 migrations against db and
+// returns a ready-to-use Store.
+func NewPostgresStore(db *database.DB) (*PostgresStore, error) {
+	if err := db.Migrate(migrationFS, "migrations"); err != nil {
+		return nil, fmt.Errorf("failed to run strike2 migrations: %w", err)
+	}
+	return &PostgresStore{db: db}, nil
+}
+
+func (s *PostgresStore) GetCachedResponse(ctx context.Context, key CacheKey, ttl time.Duration) (*CachedResponse, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT status_code, headers, body, fetched_at
+		FROM strike2.responses
+		WHERE method = $1 AND url = $2 AND fingerprint = $3 AND body_hash = $4 AND fetched_at > $5
+	`, key.Method, key.URL, key.Fingerprint, key.BodyHash, time.Now().Add(-ttl))
+
+	var (
+		statusCode int
+		headersRaw []byte
+		body       string
+		fetchedAt  time.Time
+	)
+	if err := row.Scan(&statusCode, &headersRaw, &body, &fetchedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to query response cache: %w", err)
+	}
+
+	var headers map[string]string
+	if len(headersRaw) > 0 {
+		if err := json.Unmarshal(headersRaw, &headers); err != nil {
+			return nil, fmt.Errorf("failed to decode cached headers: %w", err)
+		}
+	}
+
+	return &CachedResponse{
+		StatusCode: statusCode,
+		Headers:    headers,
+		Body:       body,
+		FetchedAt:  fetchedAt,
+	}, nil
+}
+
+func (s *PostgresStore) PutCachedResponse(ctx context.Context, key CacheKey, resp CachedResponse) error {
+	headersRaw, err := json.Marshal(resp.Headers)
+	if err != nil {
+		return fmt.Errorf("failed to encode headers: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO strike2.responses (method, url, fingerprint, body_hash, status_code, headers, body, fetched_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (method, url, fingerprint, body_hash)
+		DO UPDATE SET status_code = $5, headers = $6, body = $7, fetched_at = $8
+	`, key.Method, key.URL, key.Fingerprint, key.BodyHash, resp.StatusCode, headersRaw, resp.Body, resp.FetchedAt)
+	if err != nil {
+		return fmt.Errorf("failed to store response cache entry: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) SaveJob(ctx context.Context, job JobRecord) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO strike2.jobs (id, host, request, status, attempts, response, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (id)
+		DO UPDATE SET status = $4, attempts = $5, response = $6, updated_at = $8
+	`, job.ID, job.Host, job.Request, job.Status, job.Attempts, job.Response, job.CreatedAt, job.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to store job %s: %w", job.ID, err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) ListJobs(ctx context.Context, since time.Time, status string) ([]JobRecord, error) {
+	query := `SELECT id, host, request, status, attempts, response, created_at, updated_at FROM strike2.jobs WHERE created_at >= $1`
+	args := []interface{}{since}
+	if status != "" {
+		query += " AND status = $2"
+		args = append(args, status)
+	}
+	query += " ORDER BY created_at DESC"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []JobRecord
+	for rows.Next() {
+		var j JobRecord
+		if err := rows.Scan(&j.ID, &j.Host, &j.Request, &j.Status, &j.Attempts, &j.Response, &j.CreatedAt, &j.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan job row: %w", err)
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+func (s *PostgresStore) SaveCaptchaSolution(ctx context.Context, rec CaptchaSolutionRecord) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO strike2.captcha_solutions (id, challenge_type, url, token, provider, cost, solved_in_ms, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, rec.ID, rec.ChallengeType, rec.URL, rec.Token, rec.Provider, rec.Cost, rec.SolvedInMs, rec.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to store captcha solution %s: %w", rec.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// CacheKey identifies a previously fetched response eligible for reuse.
+type CacheKey struct {
+	Method      string
+	URL         string
+	Fingerprint string
+	BodyHash    string
+}
+
+// CachedResponse is a stored response returned from the cache on a hit.
+type CachedResponse struct {
+	StatusCode int
+	Headers    map[string]string
+	Body       string
+	FetchedAt  time.Time
+}
+
+// JobRecord is the persisted form of a scraper.Job.
+type JobRecord struct {
+	ID        string          `json:"id"`
+	Host      string          `json:"host"`
+	Request   json.RawMessage `json:"request"`
+	Status    string          `json:"status"`
+	Attempts  json.RawMessage `json:"attempts,omitempty"`
+	Response  json.RawMessage `json:"response,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// CaptchaSolutionRecord is the persisted form of a solved captcha challenge.
+type CaptchaSolutionRecord struct {
+	ID            string
+	ChallengeType string
+	URL           string
+	Token         string
+	Provider      string
+	Cost          string
+	SolvedInMs    int64
+	CreatedAt     time.Time
+}
+
+// Store persists Strike2 jobs, responses, and captcha solutions. Implementations
+// are PostgresStore (STRIKE2_DATABASE_URL set) and MemoryStore (unset, the default).
+type Store interface {
+	GetCachedResponse(ctx context.Context, key CacheKey, ttl time.Duration) (*CachedResponse, error)
+	PutCachedResponse(ctx context.Context, key CacheKey, resp CachedResponse) error
+
+	SaveJob(ctx context.Context, job JobRecord) error
+	ListJobs(ctx context.Context, since time.Time, status string) ([]JobRecord, error)
+
+	SaveCaptchaSolution(ctx context.Context, rec CaptchaSolutionRecord) error
+}
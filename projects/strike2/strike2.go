@@ -3,29 +3,50 @@ package strike2
 import (
 	"log"
 	"net/http"
+	"time"
 
+	"github.com/Goga74/platform2/internal/common/database"
+	"github.com/Goga74/platform2/internal/transport"
 	"github.com/Goga74/platform2/projects/strike2/auth"
 	"github.com/Goga74/platform2/projects/strike2/captcha"
 	"github.com/Goga74/platform2/projects/strike2/proxy"
 	"github.com/Goga74/platform2/projects/strike2/scraper"
+	"github.com/Goga74/platform2/projects/strike2/store"
 )
 
 // Strike2 is the main Strike2 project instance
 type Strike2 struct {
-	proxyHandler  *proxy.Handler
-	proxyEnabled  bool
-	simpleAuth    *auth.SimpleAuth
-	scraper       *scraper.ScraperService
-	captchaSolver *captcha.Solver
+	proxyHandler    *proxy.Handler
+	proxyEnabled    bool
+	simpleAuth      *auth.SimpleAuth
+	scraper         *scraper.ScraperService
+	captchaSolver   captcha.Solver
+	captchaProvider string
+	manualSolver    *captcha.ManualSolver
+	store           store.Store
+	fpSelector      *transport.FingerprintSelector
 }
 
 // Config holds Strike2 initialization parameters
 type Config struct {
-	CaptchaAPIKey string
-	UpstreamProxy string
-	Fingerprint   string
-	ProxyToken    string
-	Workers       int
+	CaptchaProvider string // "2captcha" (default), "anti-captcha", "capmonster", or "manual"
+	CaptchaAPIKey   string // 2Captcha key
+	AntiCaptchaKey  string
+	CapMonsterKey   string
+	UpstreamProxy   string
+	Fingerprint     string
+	ProxyToken      string
+	Workers         int
+	DatabaseURL     string // Postgres URL; falls back to an in-memory store when empty
+	CacheTTLSeconds int    // response cache TTL; 0 disables the cache
+
+	// FingerprintStrategy selects how a fingerprint is chosen per request
+	// ("fixed" (default), "random-per-request", "sticky-per-host",
+	// "round-robin", or "weighted"). Fingerprint names the fixed strategy's
+	// fingerprint. FingerprintWeights maps fingerprint name (case-insensitive)
+	// to its relative weight for the weighted strategy.
+	FingerprintStrategy string
+	FingerprintWeights  map[string]int
 }
 
 // New creates and initializes a Strike2 instance
@@ -35,8 +56,13 @@ func New(cfg Config) (*Strike2, error) {
 		workers = 500
 	}
 
+	st := openStore(cfg.DatabaseURL)
+	fpSelector := newFingerprintSelector(cfg)
+
 	s := &Strike2{
-		scraper: scraper.NewScraperService(workers),
+		scraper:    scraper.NewScraperService(workers, st, time.Duration(cfg.CacheTTLSeconds)*time.Second, fpSelector, cfg.UpstreamProxy),
+		store:      st,
+		fpSelector: fpSelector,
 	}
 
 	// Initialize simple authentication
@@ -48,19 +74,47 @@ func New(cfg Config) (*Strike2, error) {
 	}
 
 	// Initialize captcha solver
-	if cfg.CaptchaAPIKey != "" {
-		s.captchaSolver = captcha.NewSolver(cfg.CaptchaAPIKey)
-		log.Printf("[Strike2] 2Captcha integration: ENABLED")
-	} else {
-		log.Printf("[Strike2] 2Captcha integration: DISABLED (no API key)")
+	provider := cfg.CaptchaProvider
+	if provider == "" {
+		provider = "2captcha"
+	}
+	s.captchaProvider = provider
+
+	switch provider {
+	case "2captcha":
+		if cfg.CaptchaAPIKey != "" {
+			s.captchaSolver = captcha.NewTwoCaptchaSolver(cfg.CaptchaAPIKey)
+			log.Printf("[Strike2] Captcha provider: 2captcha ENABLED")
+		} else {
+			log.Printf("[Strike2] Captcha provider: 2captcha DISABLED (no API key)")
+		}
+	case "anti-captcha":
+		if cfg.AntiCaptchaKey != "" {
+			s.captchaSolver = captcha.NewAntiCaptchaSolver(cfg.AntiCaptchaKey)
+			log.Printf("[Strike2] Captcha provider: anti-captcha ENABLED")
+		} else {
+			log.Printf("[Strike2] Captcha provider: anti-captcha DISABLED (no API key)")
+		}
+	case "capmonster":
+		if cfg.CapMonsterKey != "" {
+			s.captchaSolver = captcha.NewCapMonsterSolver(cfg.CapMonsterKey)
+			log.Printf("[Strike2] Captcha provider: capmonster ENABLED")
+		} else {
+			log.Printf("[Strike2] Captcha provider: capmonster DISABLED (no API key)")
+		}
+	case "manual":
+		s.manualSolver = captcha.NewManualSolver()
+		s.captchaSolver = s.manualSolver
+		log.Printf("[Strike2] Captcha provider: manual ENABLED")
+	default:
+		log.Printf("[Strike2] Warning: unknown STRIKE2_CAPTCHA_PROVIDER %q, captcha solving disabled", provider)
 	}
 
 	// Initialize proxy handler
 	proxyConfig := &proxy.Config{
 		UpstreamProxy: cfg.UpstreamProxy,
-		Fingerprint:   cfg.Fingerprint,
 	}
-	proxyHandler, err := proxy.NewHandler(proxyConfig)
+	proxyHandler, err := proxy.NewHandler(proxyConfig, fpSelector)
 	if err != nil {
 		log.Printf("[Strike2] Warning: Failed to initialize proxy handler: %v", err)
 	} else {
@@ -75,6 +129,60 @@ func New(cfg Config) (*Strike2, error) {
 	return s, nil
 }
 
+// newFingerprintSelector builds the FingerprintSelector shared by the
+// scraper and proxy handler, falling back to the "fixed" strategy if cfg's
+// FingerprintStrategy is empty or invalid.
+func newFingerprintSelector(cfg Config) *transport.FingerprintSelector {
+	strategy := transport.FingerprintStrategy(cfg.FingerprintStrategy)
+	if strategy == "" {
+		strategy = transport.StrategyFixed
+	}
+
+	sel, err := transport.NewFingerprintSelector(strategy, cfg.Fingerprint, cfg.FingerprintWeights)
+	if err != nil {
+		log.Printf("[Strike2] Warning: %v, falling back to fixed fingerprint strategy", err)
+		sel, _ = transport.NewFingerprintSelector(transport.StrategyFixed, cfg.Fingerprint, nil)
+	}
+	return sel
+}
+
+// PinFingerprint forces host to always use the fingerprint named fpName,
+// overriding the configured FingerprintStrategy, for both the scraper and
+// the proxy handler since they share a FingerprintSelector.
+func (s *Strike2) PinFingerprint(host, fpName string) error {
+	return s.fpSelector.Pin(host, fpName)
+}
+
+// UnpinFingerprint removes any pin set for host via PinFingerprint.
+func (s *Strike2) UnpinFingerprint(host string) {
+	s.fpSelector.Unpin(host)
+}
+
+// openStore connects to Postgres and applies the strike2 schema migrations
+// when databaseURL is set, falling back to an in-memory Store (no
+// persistence across restarts) otherwise or if the connection fails.
+func openStore(databaseURL string) store.Store {
+	if databaseURL == "" {
+		log.Printf("[Strike2] Persistent store: in-memory (no STRIKE2_DATABASE_URL set)")
+		return store.NewMemoryStore()
+	}
+
+	db, err := database.Connect(databaseURL)
+	if err != nil {
+		log.Printf("[Strike2] Warning: failed to connect to database, falling back to in-memory store: %v", err)
+		return store.NewMemoryStore()
+	}
+
+	pgStore, err := store.NewPostgresStore(db)
+	if err != nil {
+		log.Printf("[Strike2] Warning: failed to run strike2 migrations, falling back to in-memory store: %v", err)
+		return store.NewMemoryStore()
+	}
+
+	log.Printf("[Strike2] Persistent store: Postgres ENABLED")
+	return pgStore
+}
+
 // WrapHandler returns a combined HTTP handler that routes between
 // the API router (gin) and the proxy handler.
 // CONNECT requests and absolute-URL requests go to the proxy.